@@ -0,0 +1,167 @@
+// Command apikeys manages the API keys stored in the database.
+//
+//	apikeys create --owner <user id> --scopes <scope,...> [--name <name>] [--expires <duration>]
+//	apikeys list
+//	apikeys revoke <id>
+//	apikeys rotate <id> [--grace <duration>]
+//
+// It reads the database and API_KEY_TTL from the same configuration as the server, including CONFIG_FILE.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/internal/config"
+	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/internal/infrastructure/db"
+	"github.com/victor-nach/postr-backend/internal/infrastructure/repositories"
+	"github.com/victor-nach/postr-backend/internal/services/apikeysservice"
+)
+
+const usage = `usage:
+  apikeys create --owner <user id> --scopes <scope,...> [--name <name>] [--expires <duration>]
+  apikeys list
+  apikeys revoke <id>
+  apikeys rotate <id> [--grace <duration>]
+
+scopes: users:read, users:write, posts:read, posts:write, admin`
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	// .env may set CONFIG_FILE
+	_ = config.LoadEnvFile()
+
+	// the commands print their own output, the config and service logs are only noise here
+	cfg, err := config.Load(zap.NewNop(), os.Getenv(config.EnvConfigFile))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gormDB, sqlDB, err := db.New(cfg)
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	defer sqlDB.Close()
+
+	svc := apikeysservice.New(repositories.NewAPIKeyRepository(gormDB), cfg.APIKeyTTL, zap.NewNop())
+	ctx := context.Background()
+
+	if err := run(ctx, svc, os.Args[1], os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, svc domain.APIKeyService, command string, args []string) error {
+	switch command {
+	case "create":
+		fs := flag.NewFlagSet("create", flag.ExitOnError)
+		owner := fs.String("owner", "", "id of the user the key acts as")
+		name := fs.String("name", "", "a label for the key")
+		scopeList := fs.String("scopes", "", "comma separated scopes to grant")
+		expires := fs.Duration("expires", 0, "how long the key lasts, defaults to API_KEY_TTL")
+		fs.Parse(args)
+
+		if *owner == "" || *scopeList == "" {
+			return fmt.Errorf("--owner and --scopes are required\n%s", usage)
+		}
+
+		var scopes domain.Scopes
+		for _, scope := range strings.Split(*scopeList, ",") {
+			scope := domain.Scope(strings.TrimSpace(scope))
+			if !slices.Contains(domain.AllScopes, scope) {
+				return fmt.Errorf("unknown scope %q\n%s", scope, usage)
+			}
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+
+		params := domain.CreateAPIKeyParams{OwnerID: *owner, Name: *name, Scopes: scopes}
+		if *expires < 0 {
+			return fmt.Errorf("--expires must be positive\n%s", usage)
+		}
+		if *expires > 0 {
+			expiresAt := time.Now().Add(*expires)
+			params.ExpiresAt = &expiresAt
+		}
+
+		issued, err := svc.Create(ctx, params)
+		if err != nil {
+			return err
+		}
+		printIssued(issued)
+
+	case "list":
+		keys, err := svc.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPREFIX\tOWNER\tNAME\tSCOPES\tCREATED\tEXPIRES\tREVOKED\tLAST USED")
+		for _, key := range keys {
+			lastUsed := orDash(key.LastUsedAt)
+			if key.LastUsedIP != nil {
+				lastUsed += " from " + *key.LastUsedIP
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Prefix, key.OwnerID, key.Name, key.Scopes, key.CreatedAt, orDash(key.ExpiresAt), orDash(key.RevokedAt), lastUsed)
+		}
+		w.Flush()
+
+	case "revoke":
+		if len(args) != 1 {
+			return fmt.Errorf("revoke takes the key id\n%s", usage)
+		}
+		if err := svc.Revoke(ctx, args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("revoked %s\n", args[0])
+
+	case "rotate":
+		if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+			return fmt.Errorf("rotate takes the key id\n%s", usage)
+		}
+		fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+		grace := fs.Duration("grace", 0, "how long the old key keeps working, by default it is revoked straight away")
+		fs.Parse(args[1:])
+
+		if *grace < 0 || fs.NArg() > 0 {
+			return fmt.Errorf("rotate takes the key id and an optional --grace\n%s", usage)
+		}
+
+		issued, err := svc.Rotate(ctx, args[0], *grace)
+		if err != nil {
+			return err
+		}
+		printIssued(issued)
+
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+
+	return nil
+}
+
+func printIssued(issued *domain.IssuedAPIKey) {
+	fmt.Printf("id:      %s\nowner:   %s\nscopes:  %s\nexpires: %s\nkey:     %s\n\nStore the key now, it cannot be shown again.\n", issued.ID, issued.OwnerID, issued.Scopes, orDash(issued.ExpiresAt), issued.Key)
+}
+
+func orDash(value *string) string {
+	if value == nil {
+		return "-"
+	}
+	return *value
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"expvar"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -9,21 +12,35 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
 	"github.com/victor-nach/postr-backend/internal/config"
+	"github.com/victor-nach/postr-backend/internal/domain"
 	"github.com/victor-nach/postr-backend/internal/handlers"
 	"github.com/victor-nach/postr-backend/internal/infrastructure/db"
 	"github.com/victor-nach/postr-backend/internal/infrastructure/repositories"
 	"github.com/victor-nach/postr-backend/internal/middlewares"
+	"github.com/victor-nach/postr-backend/internal/services/apikeysservice"
+	"github.com/victor-nach/postr-backend/internal/services/authservice"
 	"github.com/victor-nach/postr-backend/internal/services/postsservice"
+	"github.com/victor-nach/postr-backend/internal/services/quotasservice"
 	"github.com/victor-nach/postr-backend/internal/services/usersservice"
+	"github.com/victor-nach/postr-backend/pkg/cursor"
+	"github.com/victor-nach/postr-backend/pkg/jwtauth"
 	"github.com/victor-nach/postr-backend/pkg/logger"
+	"github.com/victor-nach/postr-backend/pkg/ratelimit"
 )
 
 func main() {
+	// .env may set CONFIG_FILE and APP_ENV, Load warns when it is missing
+	_ = config.LoadEnvFile()
+
+	configPath := flag.String("config", os.Getenv(config.EnvConfigFile), "path to a YAML or TOML config file")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
 	appEnv, ok := os.LookupEnv(config.EnvAppEnv)
 	if !ok {
 		appEnv = config.DefaultAppEnv
@@ -35,12 +52,43 @@ func main() {
 	}
 	defer logr.Sync()
 
-	cfg, err := config.Load(logr)
+	cfg, err := config.Load(logr, *configPath)
 	if err != nil {
 		logr.Fatal("failed to load configuration", zap.Error(err))
 	}
 
-	gormDB, sqlDB, err := db.New()
+	if *printConfig {
+		if err := cfg.WriteYAML(os.Stdout); err != nil {
+			logr.Fatal("failed to print configuration", zap.Error(err))
+		}
+		return
+	}
+
+	// the config file may set a different environment or level than the logger was built with
+	logr, logLevel, err := logger.NewLeveledLogger(cfg.AppEnv, cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer logr.Sync()
+
+	// the rate limits, CORS origins and log level are reloaded on SIGHUP or when the files change
+	configStore := config.NewStore(cfg, *configPath, logr)
+	configStore.OnReload(func(cfg *config.Config) {
+		if lvl, err := logger.ParseLevel(cfg.AppEnv, cfg.LogLevel); err == nil {
+			logLevel.SetLevel(lvl)
+		}
+	})
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go func() {
+		if err := configStore.Watch(watchCtx, hup); err != nil {
+			logr.Error("failed to watch configuration, reload with SIGHUP only", zap.Error(err))
+		}
+	}()
+
+	gormDB, sqlDB, err := db.New(cfg)
 	if err != nil {
 		logr.Fatal("failed to connect to database", zap.Error(err))
 	}
@@ -48,22 +96,98 @@ func main() {
 
 	userRepo := repositories.NewUserRepository(gormDB)
 	postRepo := repositories.NewPostRepository(gormDB)
+	apiKeyRepo := repositories.NewAPIKeyRepository(gormDB)
+	authRepo := repositories.NewAuthRepository(gormDB)
+	quotaRepo := repositories.NewQuotaRepository(gormDB)
 
 	userSvc := usersservice.New(userRepo, logr)
 	postSvc := postsservice.New(postRepo, userRepo, logr)
+	apiKeySvc := apikeysservice.New(apiKeyRepo, cfg.APIKeyTTL, logr)
+	quotaSvc := quotasservice.New(quotaRepo, quotasservice.Limits{
+		MonthlyRequests: cfg.QuotaMonthlyRequests,
+		DailyPosts:      cfg.QuotaDailyPosts,
+	}, logr)
 
-	userHandler := handlers.NewUserHandler(userSvc, logr)
-	postHandler := handlers.NewPostHandler(postSvc, logr)
+	cursors := cursor.NewCodec([]byte(cfg.CursorSecret))
 
-	mws := middlewares.New(logr, cfg)
+	userHandler := handlers.NewUserHandler(userSvc, cursors, logr)
+	postHandler := handlers.NewPostHandler(postSvc, cursors, logr)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeySvc, logr)
+	usageHandler := handlers.NewUsageHandler(quotaSvc, logr)
 
-	RunServer(cfg, userHandler, postHandler, mws, logr)
+	var tokens *jwtauth.Verifier
+	if cfg.JWTEnabled() {
+		tokens, err = jwtauth.New(jwtauth.Config{
+			HMACSecret:    cfg.JWTSecret,
+			PublicKeyFile: cfg.JWTPublicKeyFile,
+			JWKSFile:      cfg.JWTJWKSFile,
+			Audience:      cfg.JWTAudience,
+		})
+		if err != nil {
+			logr.Fatal("failed to load JWT keys", zap.Error(err))
+		}
+	}
+
+	// password logins sign their access tokens with the HS256 secret, without one they are off
+	var authHandler *handlers.AuthHandler
+	if cfg.JWTSecret != "" {
+		authSvc := authservice.New(authRepo, userRepo, jwtauth.NewSigner(cfg.JWTSecret, cfg.JWTAudience), authservice.Options{
+			AccessTokenTTL:  cfg.AccessTokenTTL,
+			RefreshTokenTTL: cfg.RefreshTokenTTL,
+			MaxFailedLogins: cfg.MaxFailedLogins,
+			LockoutDuration: cfg.LockoutDuration,
+		}, logr)
+		authHandler = handlers.NewAuthHandler(authSvc, logr)
+	} else {
+		logr.Warn("JWT_HS256_SECRET is not set, password logins are disabled")
+	}
+
+	// key usage is written in the background so authenticating a request never waits on the database
+	usage := apikeysservice.NewUsageTracker(apiKeyRepo, cfg.APIKeyUsageFlushInterval, logr)
+	usageCtx, stopUsage := context.WithCancel(context.Background())
+	usageDone := make(chan struct{})
+	go func() {
+		defer close(usageDone)
+		usage.Run(usageCtx)
+	}()
+
+	var limiter middlewares.LimiterStore
+	switch cfg.RateLimitStore {
+	case config.RateLimitStoreRedis:
+		redisOpts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			logr.Fatal("failed to parse REDIS_URL", zap.Error(err))
+		}
+		redisClient := redis.NewClient(redisOpts)
+		defer redisClient.Close()
+
+		pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = redisClient.Ping(pingCtx).Err()
+		cancel()
+		if err != nil {
+			logr.Fatal("failed to connect to redis", zap.Error(err))
+		}
+		limiter = ratelimit.NewRedisStore(redisClient, "postr:ratelimit:")
+	default:
+		limiter = ratelimit.NewMemoryStore(ratelimit.Options{MaxKeys: cfg.RateLimitMaxKeys, TTL: cfg.RateLimitKeyTTL})
+	}
+
+	mws := middlewares.New(logr, configStore, apiKeySvc, tokens, usage, limiter, quotaSvc)
+
+	RunServer(cfg, userHandler, postHandler, apiKeyHandler, authHandler, usageHandler, mws, logr)
+
+	// write the usage recorded since the last flush before the database is closed
+	stopUsage()
+	<-usageDone
 }
 
 // RunServer creates and mounts the router, starts the server in a goroutine,
 // and listens for OS signals to gracefully shutdown
-func RunServer(cfg *config.Config, userHandler *handlers.UserHandler, postHandler *handlers.PostHandler, mws *middlewares.Service, logr *zap.Logger) {
-	router := createRouter(userHandler, postHandler, mws)
+func RunServer(cfg *config.Config, userHandler *handlers.UserHandler, postHandler *handlers.PostHandler, apiKeyHandler *handlers.APIKeyHandler, authHandler *handlers.AuthHandler, usageHandler *handlers.UsageHandler, mws *middlewares.Service, logr *zap.Logger) {
+	router, err := createRouter(cfg.TrustedProxies, userHandler, postHandler, apiKeyHandler, authHandler, usageHandler, mws)
+	if err != nil {
+		logr.Fatal("failed to create router", zap.Error(err))
+	}
 
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -94,31 +218,70 @@ func RunServer(cfg *config.Config, userHandler *handlers.UserHandler, postHandle
 	logr.Info("Server exiting")
 }
 
-func createRouter(userHandler *handlers.UserHandler, postHandler *handlers.PostHandler, mws *middlewares.Service) http.Handler {
-	router := gin.Default()
-	router.Use(mws.AuthMiddleware())
-	router.Use(mws.RateLimitMiddleware())
-
-	corsConfig := cors.Config{
-		AllowOrigins:  []string{"*"},
-		AllowMethods:  []string{"GET", "POST", "DELETE", "OPTIONS"},
-		AllowHeaders:  []string{"Origin", "Content-Length", "Content-Type", "X-API-Key"},
-		ExposeHeaders: []string{"Content-Length"},
-		MaxAge:        12 * time.Hour,
+// createRouter mounts the routes, authHandler is nil when password logins are disabled.
+// X-Forwarded-For is only believed from trustedProxies, so clients cannot pick the IP they are
+// rate limited and recorded by
+func createRouter(trustedProxies []string, userHandler *handlers.UserHandler, postHandler *handlers.PostHandler, apiKeyHandler *handlers.APIKeyHandler, authHandler *handlers.AuthHandler, usageHandler *handlers.UsageHandler, mws *middlewares.Service) (http.Handler, error) {
+	engine := gin.Default()
+	if err := engine.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("error setting trusted proxies: %w", err)
 	}
-	router.Use(cors.New(corsConfig))
 
-	router.GET("/users", userHandler.ListUsers)
-	router.GET("/users/count", userHandler.CountUsers)
-	router.GET("/users/:id", userHandler.GetUserByID)
+	engine.Use(mws.CORSMiddleware())
+
+	// the login endpoints authenticate with a password or refresh token, not a key,
+	// so they are rate limited per client IP
+	if authHandler != nil {
+		auth := engine.Group("/auth", mws.RateLimitMiddleware())
+		auth.POST("/login", authHandler.Login)
+		auth.POST("/refresh", authHandler.Refresh)
+		auth.POST("/logout", authHandler.Logout)
+	}
+
+	authenticated := engine.Group("", mws.AuthMiddleware(), mws.RateLimitMiddleware())
+
+	// checking usage does not count against the quota, so a key that has used it up can still see when it resets
+	authenticated.GET("/me/usage", usageHandler.GetMyUsage)
+
+	router := authenticated.Group("", mws.RequireQuota(domain.QuotaRequests))
+
+	usersRead := mws.RequireScope(domain.ScopeUsersRead)
+	usersWrite := mws.RequireScope(domain.ScopeUsersWrite)
+	postsRead := mws.RequireScope(domain.ScopePostsRead)
+	postsWrite := mws.RequireScope(domain.ScopePostsWrite)
+
+	router.GET("/users", usersRead, userHandler.ListUsers)
+	router.POST("/users", usersWrite, userHandler.CreateUser)
+	router.GET("/users/count", usersRead, userHandler.CountUsers)
+	router.GET("/users/:id", usersRead, userHandler.GetUserByID)
+	router.PUT("/users/:id", usersWrite, userHandler.UpdateUser)
+	router.PATCH("/users/:id", usersWrite, userHandler.PatchUser)
+	router.DELETE("/users/:id", usersWrite, userHandler.DeleteUser)
+	if authHandler != nil {
+		router.PUT("/users/:id/password", usersWrite, authHandler.SetPassword)
+	}
+
+	router.POST("/posts", postsWrite, mws.RequireQuota(domain.QuotaPosts), postHandler.CreatePost)
+	router.DELETE("/posts/:id", postsWrite, postHandler.DeletePost)
+	router.GET("/posts", postsRead, postHandler.ListPostsByUserID)
+	router.GET("/posts/search", postsRead, postHandler.SearchPosts)
+	router.GET("/posts/:id", postsRead, postHandler.GetPostByID)
+	router.PATCH("/posts/:id", postsWrite, postHandler.UpdatePost)
+	router.GET("/posts/:id/revisions", postsRead, postHandler.ListPostRevisions)
+
+	router.GET("/feed", postsRead, postHandler.Feed)
 
-	router.POST("/posts", postHandler.CreatePost)
-	router.DELETE("/posts/:id", postHandler.DeletePost)
-	router.GET("/posts", postHandler.ListPostsByUserID)
+	admin := router.Group("/admin", mws.RequireScope(domain.ScopeAdmin))
+	admin.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+	admin.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+	admin.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+	admin.POST("/api-keys/:id/rotate", apiKeyHandler.RotateAPIKey)
+	// process metrics, including the config_reloads counts
+	admin.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 
 	router.GET("/", func(c *gin.Context) {
 		c.String(http.StatusOK, "Welcome to postr api")
 	})
 
-	return router
+	return engine, nil
 }
@@ -15,7 +15,11 @@ import (
 )
 
 func main() {
+	// .env may set CONFIG_FILE and APP_ENV, Load warns when it is missing
+	_ = config.LoadEnvFile()
+
 	seed := flag.Bool("seed", false, "seed the database after migrations")
+	configPath := flag.String("config", os.Getenv(config.EnvConfigFile), "path to a YAML or TOML config file")
 	flag.Parse()
 
 	appEnv, ok := os.LookupEnv(config.EnvAppEnv)
@@ -29,15 +33,17 @@ func main() {
 	}
 	defer logr.Sync()
 
-	db, sqlDB, err := db.New()
+	cfg, err := config.Load(logr, *configPath)
 	if err != nil {
-		logr.Fatal("failed to connect to database", zap.Error(err))
+		logr.Fatal("failed to load configuration", zap.Error(err))
 	}
-	defer sqlDB.Close()
 
-	if err := migrator.Migrate(sqlDB, "file://migrations"); err != nil {
+	// db.New applies the migrations
+	db, sqlDB, err := db.New(cfg)
+	if err != nil {
 		logr.Fatal("migration failed", zap.Error(err))
 	}
+	defer sqlDB.Close()
 
 	if *seed {
 		if err := migrator.Seed(db); err != nil {
@@ -1,26 +1,86 @@
 package config
 
 import (
-	"encoding/json"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"os"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/pkg/ratelimit"
 )
 
 const (
 	// Environment variable keys
-	EnvPort         = "PORT"
-	EnvAppEnv       = "APP_ENV"
-	EnvApiKeys      = "API_KEYS"
-	EnvRateLimitKey = "RATE_LIMIT_RPS"
+	EnvConfigFile               = "CONFIG_FILE"
+	EnvPort                     = "PORT"
+	EnvAppEnv                   = "APP_ENV"
+	EnvApiKeys                  = "API_KEYS"
+	EnvRateLimitKey             = "RATE_LIMIT_RPS"
+	EnvCursorSecret             = "CURSOR_SECRET"
+	EnvJWTSecret                = "JWT_HS256_SECRET"
+	EnvJWTPublicKeyFile         = "JWT_RS256_PUBLIC_KEY_FILE"
+	EnvJWTJWKSFile              = "JWT_JWKS_FILE"
+	EnvJWTAudience              = "JWT_AUDIENCE"
+	EnvAccessTokenTTL           = "AUTH_ACCESS_TOKEN_TTL"
+	EnvRefreshTokenTTL          = "AUTH_REFRESH_TOKEN_TTL"
+	EnvMaxFailedLogins          = "AUTH_MAX_FAILED_LOGINS"
+	EnvLockoutDuration          = "AUTH_LOCKOUT_DURATION"
+	EnvAPIKeyTTL                = "API_KEY_TTL"
+	EnvAPIKeyUsageFlushInterval = "API_KEY_USAGE_FLUSH_INTERVAL"
+	EnvRateLimitMaxKeys         = "RATE_LIMIT_MAX_KEYS"
+	EnvRateLimitKeyTTL          = "RATE_LIMIT_KEY_TTL"
+	EnvRateLimitPolicyFile      = "RATE_LIMIT_POLICY_FILE"
+	EnvRateLimitStore           = "RATE_LIMIT_STORE"
+	EnvRedisURL                 = "REDIS_URL"
+	EnvQuotaMonthlyRequests     = "QUOTA_MONTHLY_REQUESTS"
+	EnvQuotaDailyPosts          = "QUOTA_DAILY_POSTS"
+	EnvLogLevel                 = "LOG_LEVEL"
+	EnvCORSAllowedOrigins       = "CORS_ALLOWED_ORIGINS"
+	EnvTrustedProxies           = "TRUSTED_PROXIES"
+	EnvDBDriver                 = "DB_DRIVER"
+	EnvDBDSN                    = "DB_DSN"
+	EnvDBMaxOpenConns           = "DB_MAX_OPEN_CONNS"
+	EnvDBMaxIdleConns           = "DB_MAX_IDLE_CONNS"
+	EnvDBConnMaxLifetime        = "DB_CONN_MAX_LIFETIME"
+	EnvDBConnMaxIdleTime        = "DB_CONN_MAX_IDLE_TIME"
+	EnvDBBusyTimeout            = "DB_BUSY_TIMEOUT"
+	EnvDBJournalMode            = "DB_JOURNAL_MODE"
+	EnvDBMigrations             = "DB_MIGRATIONS"
 
 	// Default values
-	DefaultPort         = "8080"
-	DefaultAppEnv       = "development"
-	DefaultRateLimitEnv = "5"
+	DefaultPort                     = "8080"
+	DefaultAppEnv                   = "development"
+	DefaultRateLimitRPS             = 5
+	DefaultAccessTokenTTL           = 15 * time.Minute
+	DefaultRefreshTokenTTL          = 30 * 24 * time.Hour
+	DefaultMaxFailedLogins          = 5
+	DefaultLockoutDuration          = 15 * time.Minute
+	DefaultAPIKeyTTL                = 90 * 24 * time.Hour
+	DefaultAPIKeyUsageFlushInterval = 30 * time.Second
+	DefaultRateLimitMaxKeys         = 100_000
+	DefaultRateLimitKeyTTL          = 10 * time.Minute
+	DefaultQuotaMonthlyRequests     = 100_000
+	DefaultQuotaDailyPosts          = 100
+	DefaultDBDSN                    = "file:data/app.db"
+	DefaultDBMaxOpenConns           = 2
+	DefaultDBMaxIdleConns           = 2
+	DefaultDBConnMaxLifetime        = time.Hour
+	DefaultDBConnMaxIdleTime        = 10 * time.Minute
+	DefaultDBBusyTimeout            = 5 * time.Second
+	DefaultDBJournalMode            = "WAL"
+
+	// Database drivers
+	DBDriverSQLite   = "sqlite"
+	DBDriverPostgres = "postgres"
+
+	// Rate limit stores
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
 
 	// APP envs
 	ProdEnv = "production"
@@ -31,68 +91,143 @@ const (
 type Config struct {
 	Port       string
 	AppEnv     string
-	APIKeys    map[string]string
 	RateLimtPS int
+	// The rate limiter keeps at most RateLimitMaxKeys callers and forgets those idle for RateLimitKeyTTL
+	RateLimitMaxKeys int
+	RateLimitKeyTTL  time.Duration
+	// RateLimitPolicies comes from RateLimitPolicyFile or the config file, or allows RateLimtPS on every route without either
+	RateLimitPolicies   ratelimit.Policies
+	RateLimitPolicyFile string
+	// RateLimitStore is memory, which limits each instance on its own, or redis, which shares the limits through RedisURL
+	RateLimitStore string
+	RedisURL       string
+	// Every API key may make QuotaMonthlyRequests requests a month and create QuotaDailyPosts posts a day
+	QuotaMonthlyRequests int
+	QuotaDailyPosts      int
+	// CursorSecret signs pagination cursors
+	CursorSecret string
+	// Bearer tokens are only accepted when at least one of the JWT keys is set
+	JWTSecret        string
+	JWTPublicKeyFile string
+	JWTJWKSFile      string
+	JWTAudience      string
+	// Password logins issue access tokens signed with JWTSecret
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	MaxFailedLogins int
+	LockoutDuration time.Duration
+	// APIKeyTTL is how long new API keys last unless they are created with an expiry
+	APIKeyTTL                time.Duration
+	APIKeyUsageFlushInterval time.Duration
+	// LogLevel overrides the environment's default of debug in development and info in production
+	LogLevel string
+	// CORSAllowedOrigins may call the API from a browser, * allows any origin
+	CORSAllowedOrigins []string
+	// TrustedProxies are the IPs and CIDRs whose X-Forwarded-For header is believed when working out
+	// the client IP, with none the client IP is always the address of the connection
+	TrustedProxies []string
+	// DBDriver is sqlite or postgres. DBDSN is the database to connect to, for sqlite DBBusyTimeout
+	// and DBJournalMode are added to it as pragmas and are ignored by postgres
+	DBDriver          string
+	DBDSN             string
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	DBBusyTimeout     time.Duration
+	DBJournalMode     string
+	// DBMigrations is a migration source URL such as file://migrations/sqlite, the migrations built into the binary are used without one
+	DBMigrations string
 }
 
-// Load reads configuration from the environment and loads the .env file in the project root if available
-// Sets default values if applicable
-func Load(logger *zap.Logger) (*Config, error) {
-	err := godotenv.Load()
-	if err != nil {
-		logger.Warn("error loading .env file, using default values")
-	}
+// JWTEnabled reports whether bearer tokens are accepted
+func (c *Config) JWTEnabled() bool {
+	return c.JWTSecret != "" || c.JWTPublicKeyFile != "" || c.JWTJWKSFile != ""
+}
 
-	port, ok := os.LookupEnv(EnvPort)
-	if !ok {
-		port = DefaultPort
-	}
+// LoadEnvFile loads the .env file in the project root into the environment, variables that
+// are already set keep their values. Load calls it, commands call it first when they read
+// CONFIG_FILE or APP_ENV themselves.
+func LoadEnvFile() error {
+	return godotenv.Load()
+}
 
-	appEnv, ok := os.LookupEnv(EnvAppEnv)
-	if !ok {
-		appEnv = DefaultAppEnv
+// Load builds the configuration from the defaults, then the config file at path if one is given,
+// then the environment, loading the .env file in the project root if available.
+// Every invalid setting is reported at once rather than falling back to a default.
+func Load(logger *zap.Logger, path string) (*Config, error) {
+	err := LoadEnvFile()
+	if err != nil {
+		logger.Warn("error loading .env file, using default values")
 	}
 
-	rpsStr, ok := os.LookupEnv(EnvRateLimitKey)
-	if !ok {
-		rpsStr = DefaultRateLimitEnv
-	}
-	rps, err := strconv.Atoi(rpsStr)
+	cfg, err := load(path)
 	if err != nil {
-		rpsStr = DefaultRateLimitEnv
+		return nil, err
 	}
 
-	apiKeysStr, ok := os.LookupEnv(EnvApiKeys)
-	if !ok {
-		logger.Warn("no api keys loaded")
-	}
-	apiKeys, err := parseAPIKeys(apiKeysStr)
-	if err != nil {
-		logger.Warn("error retrieving api keys", zap.Error(err))
+	// API keys live in the database now, see cmd/apikeys
+	if _, ok := os.LookupEnv(EnvApiKeys); ok {
+		logger.Warn("API_KEYS is no longer read, create keys with the apikeys command instead")
 	}
 
-	cfg := &Config{
-		Port:       port,
-		AppEnv:     appEnv,
-		APIKeys:    apiKeys,
-		RateLimtPS: rps,
+	if cfg.CursorSecret == "" {
+		logger.Warn("no cursor secret set, generating one - cursors will not survive a restart")
+		cfg.CursorSecret, err = randomSecret()
+		if err != nil {
+			return nil, fmt.Errorf("error generating cursor secret: %w", err)
+		}
 	}
 
 	logger.Info("Configuration loaded",
+		zap.String("config_file", path),
 		zap.String("port", cfg.Port),
 		zap.String("app_env", cfg.AppEnv),
-		zap.Int("api_key_count", len(cfg.APIKeys)),
+		zap.Bool("jwt_enabled", cfg.JWTEnabled()),
+		zap.Int("rate_limit_policies", len(cfg.RateLimitPolicies.Rules)),
+		zap.String("rate_limit_store", cfg.RateLimitStore),
 	)
 
 	return cfg, nil
 }
 
-func parseAPIKeys(keysStr string) (map[string]string, error) {
-	var apiKeys map[string]string
+// load reads the config file and the environment over the defaults and validates the result
+func load(path string) (*Config, error) {
+	f := defaultFile()
+	if path != "" {
+		if err := f.decode(path); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := json.Unmarshal([]byte(keysStr), &apiKeys); err != nil {
-		return nil, fmt.Errorf("error unmarshalling json: %w", err)
+	var p problems
+	f.applyEnv(&p)
+	cfg := f.resolve(&p)
+	if err := p.err(); err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
+
+// problems collects every invalid setting so startup reports them together
+type problems []string
 
-	return apiKeys, nil
+// add records a problem with a setting, naming both its config file key and its env var
+func (p *problems) add(key, env, format string, args ...any) {
+	*p = append(*p, fmt.Sprintf("%s (%s): %s", key, env, fmt.Sprintf(format, args...)))
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration: %s", strings.Join(p, "; "))
+}
+
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,489 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+
+	"github.com/victor-nach/postr-backend/pkg/ratelimit"
+)
+
+// redacted replaces secrets when the configuration is printed
+const redacted = "[redacted]"
+
+// dsnPassword matches the password of a key/value DSN such as postgres' host=db password=secret
+var dsnPassword = regexp.MustCompile(`(password=)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// journalModes are the sqlite journal modes
+var journalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+
+// fileConfig is the schema of a YAML or TOML config file, durations use Go syntax such as 15m.
+// Keys that are not part of it are rejected so that typos do not go unnoticed.
+type fileConfig struct {
+	Port           int              `yaml:"port" toml:"port"`
+	AppEnv         string           `yaml:"app_env" toml:"app_env"`
+	CursorSecret   string           `yaml:"cursor_secret" toml:"cursor_secret"`
+	RateLimit      rateLimitSection `yaml:"rate_limit" toml:"rate_limit"`
+	JWT            jwtSection       `yaml:"jwt" toml:"jwt"`
+	Auth           authSection      `yaml:"auth" toml:"auth"`
+	APIKeys        apiKeysSection   `yaml:"api_keys" toml:"api_keys"`
+	Quotas         quotasSection    `yaml:"quotas" toml:"quotas"`
+	LogLevel       string           `yaml:"log_level" toml:"log_level"`
+	CORS           corsSection      `yaml:"cors" toml:"cors"`
+	TrustedProxies []string         `yaml:"trusted_proxies" toml:"trusted_proxies"`
+	Database       databaseSection  `yaml:"database" toml:"database"`
+}
+
+type rateLimitSection struct {
+	RPS        int    `yaml:"rps" toml:"rps"`
+	MaxKeys    int    `yaml:"max_keys" toml:"max_keys"`
+	KeyTTL     string `yaml:"key_ttl" toml:"key_ttl"`
+	Store      string `yaml:"store" toml:"store"`
+	RedisURL   string `yaml:"redis_url" toml:"redis_url"`
+	PolicyFile string `yaml:"policy_file" toml:"policy_file"`
+	// Policies may be given inline instead of in PolicyFile
+	Policies *ratelimit.Policies `yaml:"policies,omitempty" toml:"policies,omitempty"`
+}
+
+type jwtSection struct {
+	HS256Secret        string `yaml:"hs256_secret" toml:"hs256_secret"`
+	RS256PublicKeyFile string `yaml:"rs256_public_key_file" toml:"rs256_public_key_file"`
+	JWKSFile           string `yaml:"jwks_file" toml:"jwks_file"`
+	Audience           string `yaml:"audience" toml:"audience"`
+}
+
+type authSection struct {
+	AccessTokenTTL  string `yaml:"access_token_ttl" toml:"access_token_ttl"`
+	RefreshTokenTTL string `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+	MaxFailedLogins int    `yaml:"max_failed_logins" toml:"max_failed_logins"`
+	LockoutDuration string `yaml:"lockout_duration" toml:"lockout_duration"`
+}
+
+type apiKeysSection struct {
+	TTL                string `yaml:"ttl" toml:"ttl"`
+	UsageFlushInterval string `yaml:"usage_flush_interval" toml:"usage_flush_interval"`
+}
+
+type databaseSection struct {
+	Driver          string `yaml:"driver" toml:"driver"`
+	DSN             string `yaml:"dsn" toml:"dsn"`
+	MaxOpenConns    int    `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int    `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime string `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime string `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	BusyTimeout     string `yaml:"busy_timeout" toml:"busy_timeout"`
+	JournalMode     string `yaml:"journal_mode" toml:"journal_mode"`
+	Migrations      string `yaml:"migrations" toml:"migrations"`
+}
+
+type corsSection struct {
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+type quotasSection struct {
+	MonthlyRequests int `yaml:"monthly_requests" toml:"monthly_requests"`
+	DailyPosts      int `yaml:"daily_posts" toml:"daily_posts"`
+}
+
+func defaultFile() fileConfig {
+	port, _ := strconv.Atoi(DefaultPort)
+	return fileConfig{
+		Port:   port,
+		AppEnv: DefaultAppEnv,
+		RateLimit: rateLimitSection{
+			RPS:     DefaultRateLimitRPS,
+			MaxKeys: DefaultRateLimitMaxKeys,
+			KeyTTL:  formatDuration(DefaultRateLimitKeyTTL),
+			Store:   RateLimitStoreMemory,
+		},
+		Auth: authSection{
+			AccessTokenTTL:  formatDuration(DefaultAccessTokenTTL),
+			RefreshTokenTTL: formatDuration(DefaultRefreshTokenTTL),
+			MaxFailedLogins: DefaultMaxFailedLogins,
+			LockoutDuration: formatDuration(DefaultLockoutDuration),
+		},
+		APIKeys: apiKeysSection{
+			TTL:                formatDuration(DefaultAPIKeyTTL),
+			UsageFlushInterval: formatDuration(DefaultAPIKeyUsageFlushInterval),
+		},
+		Quotas: quotasSection{
+			MonthlyRequests: DefaultQuotaMonthlyRequests,
+			DailyPosts:      DefaultQuotaDailyPosts,
+		},
+		CORS: corsSection{AllowedOrigins: []string{"*"}},
+		Database: databaseSection{
+			Driver:          DBDriverSQLite,
+			MaxOpenConns:    DefaultDBMaxOpenConns,
+			MaxIdleConns:    DefaultDBMaxIdleConns,
+			ConnMaxLifetime: formatDuration(DefaultDBConnMaxLifetime),
+			ConnMaxIdleTime: formatDuration(DefaultDBConnMaxIdleTime),
+			BusyTimeout:     formatDuration(DefaultDBBusyTimeout),
+			JournalMode:     DefaultDBJournalMode,
+		},
+	}
+}
+
+// decode reads the config file over the values already set, its format comes from the extension
+func (f *fileConfig) decode(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		// an empty file decodes to io.EOF and leaves the defaults alone
+		if err := dec.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("error parsing config file %s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields()
+		if err := dec.Decode(f); err != nil {
+			var strict *toml.StrictMissingError
+			if errors.As(err, &strict) {
+				keys := make([]string, len(strict.Errors))
+				for i, e := range strict.Errors {
+					keys[i] = strings.Join(e.Key(), ".")
+				}
+				return fmt.Errorf("error parsing config file %s: unknown keys %s", path, strings.Join(keys, ", "))
+			}
+			return fmt.Errorf("error parsing config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s must end in .yaml, .yml or .toml", path)
+	}
+
+	if f.RateLimit.Policies != nil && f.RateLimit.PolicyFile != "" {
+		return fmt.Errorf("config file %s sets both rate_limit.policies and rate_limit.policy_file", path)
+	}
+	return nil
+}
+
+// applyEnv overrides the values with those set in the environment. Variables that are set
+// but empty are ignored like unset ones, so a .env file listing every variable does not
+// blank out the config file.
+func (f *fileConfig) applyEnv(p *problems) {
+	str := func(env string, dst *string) {
+		if v := os.Getenv(env); v != "" {
+			*dst = v
+		}
+	}
+	num := func(key, env string, dst *int) {
+		v := os.Getenv(env)
+		if v == "" {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			p.add(key, env, "%q is not a number", v)
+			return
+		}
+		*dst = n
+	}
+
+	num("port", EnvPort, &f.Port)
+	str(EnvAppEnv, &f.AppEnv)
+	str(EnvCursorSecret, &f.CursorSecret)
+
+	num("rate_limit.rps", EnvRateLimitKey, &f.RateLimit.RPS)
+	num("rate_limit.max_keys", EnvRateLimitMaxKeys, &f.RateLimit.MaxKeys)
+	str(EnvRateLimitKeyTTL, &f.RateLimit.KeyTTL)
+	str(EnvRateLimitStore, &f.RateLimit.Store)
+	str(EnvRedisURL, &f.RateLimit.RedisURL)
+	if v := os.Getenv(EnvRateLimitPolicyFile); v != "" {
+		// a policy file from the environment replaces the policies in the config file
+		f.RateLimit.PolicyFile = v
+		f.RateLimit.Policies = nil
+	}
+
+	str(EnvJWTSecret, &f.JWT.HS256Secret)
+	str(EnvJWTPublicKeyFile, &f.JWT.RS256PublicKeyFile)
+	str(EnvJWTJWKSFile, &f.JWT.JWKSFile)
+	str(EnvJWTAudience, &f.JWT.Audience)
+
+	str(EnvAccessTokenTTL, &f.Auth.AccessTokenTTL)
+	str(EnvRefreshTokenTTL, &f.Auth.RefreshTokenTTL)
+	num("auth.max_failed_logins", EnvMaxFailedLogins, &f.Auth.MaxFailedLogins)
+	str(EnvLockoutDuration, &f.Auth.LockoutDuration)
+
+	str(EnvAPIKeyTTL, &f.APIKeys.TTL)
+	str(EnvAPIKeyUsageFlushInterval, &f.APIKeys.UsageFlushInterval)
+
+	num("quotas.monthly_requests", EnvQuotaMonthlyRequests, &f.Quotas.MonthlyRequests)
+	num("quotas.daily_posts", EnvQuotaDailyPosts, &f.Quotas.DailyPosts)
+
+	str(EnvDBDriver, &f.Database.Driver)
+	str(EnvDBDSN, &f.Database.DSN)
+	num("database.max_open_conns", EnvDBMaxOpenConns, &f.Database.MaxOpenConns)
+	num("database.max_idle_conns", EnvDBMaxIdleConns, &f.Database.MaxIdleConns)
+	str(EnvDBConnMaxLifetime, &f.Database.ConnMaxLifetime)
+	str(EnvDBConnMaxIdleTime, &f.Database.ConnMaxIdleTime)
+	str(EnvDBBusyTimeout, &f.Database.BusyTimeout)
+	str(EnvDBJournalMode, &f.Database.JournalMode)
+	str(EnvDBMigrations, &f.Database.Migrations)
+
+	str(EnvLogLevel, &f.LogLevel)
+	if v := os.Getenv(EnvCORSAllowedOrigins); v != "" {
+		f.CORS.AllowedOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				f.CORS.AllowedOrigins = append(f.CORS.AllowedOrigins, origin)
+			}
+		}
+	}
+	if v := os.Getenv(EnvTrustedProxies); v != "" {
+		f.TrustedProxies = nil
+		for _, proxy := range strings.Split(v, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				f.TrustedProxies = append(f.TrustedProxies, proxy)
+			}
+		}
+	}
+}
+
+// resolve validates the values and converts them to a Config, recording every problem in p
+func (f fileConfig) resolve(p *problems) *Config {
+	positive := func(key, env string, n int) int {
+		if n < 1 {
+			p.add(key, env, "must be a positive number, got %d", n)
+		}
+		return n
+	}
+	duration := func(key, env, v string) time.Duration {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			p.add(key, env, "must be a positive duration such as 15m, got %q", v)
+		}
+		return d
+	}
+
+	cfg := &Config{
+		Port:                     strconv.Itoa(f.Port),
+		AppEnv:                   f.AppEnv,
+		RateLimtPS:               positive("rate_limit.rps", EnvRateLimitKey, f.RateLimit.RPS),
+		RateLimitMaxKeys:         positive("rate_limit.max_keys", EnvRateLimitMaxKeys, f.RateLimit.MaxKeys),
+		RateLimitKeyTTL:          duration("rate_limit.key_ttl", EnvRateLimitKeyTTL, f.RateLimit.KeyTTL),
+		RateLimitPolicyFile:      f.RateLimit.PolicyFile,
+		RateLimitStore:           f.RateLimit.Store,
+		RedisURL:                 f.RateLimit.RedisURL,
+		QuotaMonthlyRequests:     positive("quotas.monthly_requests", EnvQuotaMonthlyRequests, f.Quotas.MonthlyRequests),
+		QuotaDailyPosts:          positive("quotas.daily_posts", EnvQuotaDailyPosts, f.Quotas.DailyPosts),
+		CursorSecret:             f.CursorSecret,
+		JWTSecret:                f.JWT.HS256Secret,
+		JWTPublicKeyFile:         f.JWT.RS256PublicKeyFile,
+		JWTJWKSFile:              f.JWT.JWKSFile,
+		JWTAudience:              f.JWT.Audience,
+		AccessTokenTTL:           duration("auth.access_token_ttl", EnvAccessTokenTTL, f.Auth.AccessTokenTTL),
+		RefreshTokenTTL:          duration("auth.refresh_token_ttl", EnvRefreshTokenTTL, f.Auth.RefreshTokenTTL),
+		MaxFailedLogins:          positive("auth.max_failed_logins", EnvMaxFailedLogins, f.Auth.MaxFailedLogins),
+		LockoutDuration:          duration("auth.lockout_duration", EnvLockoutDuration, f.Auth.LockoutDuration),
+		APIKeyTTL:                duration("api_keys.ttl", EnvAPIKeyTTL, f.APIKeys.TTL),
+		APIKeyUsageFlushInterval: duration("api_keys.usage_flush_interval", EnvAPIKeyUsageFlushInterval, f.APIKeys.UsageFlushInterval),
+		LogLevel:                 f.LogLevel,
+		CORSAllowedOrigins:       f.CORS.AllowedOrigins,
+		TrustedProxies:           f.TrustedProxies,
+		DBDriver:                 f.Database.Driver,
+		DBDSN:                    f.Database.DSN,
+		DBMaxOpenConns:           positive("database.max_open_conns", EnvDBMaxOpenConns, f.Database.MaxOpenConns),
+		DBMaxIdleConns:           positive("database.max_idle_conns", EnvDBMaxIdleConns, f.Database.MaxIdleConns),
+		DBConnMaxLifetime:        duration("database.conn_max_lifetime", EnvDBConnMaxLifetime, f.Database.ConnMaxLifetime),
+		DBConnMaxIdleTime:        duration("database.conn_max_idle_time", EnvDBConnMaxIdleTime, f.Database.ConnMaxIdleTime),
+		DBBusyTimeout:            duration("database.busy_timeout", EnvDBBusyTimeout, f.Database.BusyTimeout),
+		DBJournalMode:            strings.ToUpper(f.Database.JournalMode),
+		DBMigrations:             f.Database.Migrations,
+	}
+
+	if f.Port < 1 || f.Port > 65535 {
+		p.add("port", EnvPort, "must be between 1 and 65535, got %d", f.Port)
+	}
+	if f.AppEnv != DevEnv && f.AppEnv != ProdEnv {
+		p.add("app_env", EnvAppEnv, "must be %s or %s, got %q", DevEnv, ProdEnv, f.AppEnv)
+	}
+
+	if f.LogLevel != "" {
+		if _, err := zapcore.ParseLevel(f.LogLevel); err != nil {
+			p.add("log_level", EnvLogLevel, "must be debug, info, warn or error, got %q", f.LogLevel)
+		}
+	}
+	if len(f.CORS.AllowedOrigins) == 0 {
+		p.add("cors.allowed_origins", EnvCORSAllowedOrigins, "must list at least one origin, or *")
+	}
+	for _, origin := range f.CORS.AllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			p.add("cors.allowed_origins", EnvCORSAllowedOrigins, "%q must be * or start with http:// or https://", origin)
+		}
+	}
+	for _, proxy := range f.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				p.add("trusted_proxies", EnvTrustedProxies, "%q must be an IP address or a CIDR range", proxy)
+			}
+		}
+	}
+
+	switch cfg.DBDriver {
+	case DBDriverSQLite:
+		if cfg.DBDSN == "" {
+			cfg.DBDSN = DefaultDBDSN
+		}
+		if !slices.Contains(journalModes, cfg.DBJournalMode) {
+			p.add("database.journal_mode", EnvDBJournalMode, "must be one of %s, got %q", strings.Join(journalModes, ", "), f.Database.JournalMode)
+		}
+	case DBDriverPostgres:
+		if cfg.DBDSN == "" {
+			p.add("database.dsn", EnvDBDSN, "is required when the driver is %s", DBDriverPostgres)
+		}
+	default:
+		p.add("database.driver", EnvDBDriver, "must be %s or %s, got %q", DBDriverSQLite, DBDriverPostgres, cfg.DBDriver)
+	}
+	if cfg.DBMigrations != "" && !strings.Contains(cfg.DBMigrations, "://") {
+		p.add("database.migrations", EnvDBMigrations, "must be a source URL such as file://migrations, got %q", cfg.DBMigrations)
+	}
+
+	if cfg.JWTEnabled() && cfg.JWTAudience == "" {
+		p.add("jwt.audience", EnvJWTAudience, "is required when JWT keys are configured")
+	}
+
+	switch cfg.RateLimitStore {
+	case RateLimitStoreMemory:
+	case RateLimitStoreRedis:
+		if cfg.RedisURL == "" {
+			p.add("rate_limit.redis_url", EnvRedisURL, "is required when rate_limit.store is %s", RateLimitStoreRedis)
+		}
+	default:
+		p.add("rate_limit.store", EnvRateLimitStore, "must be %s or %s, got %q", RateLimitStoreMemory, RateLimitStoreRedis, cfg.RateLimitStore)
+	}
+
+	switch {
+	case f.RateLimit.PolicyFile != "":
+		policies, err := ratelimit.LoadPolicies(f.RateLimit.PolicyFile)
+		if err != nil {
+			p.add("rate_limit.policy_file", EnvRateLimitPolicyFile, "%v", err)
+		}
+		cfg.RateLimitPolicies = policies
+	case f.RateLimit.Policies != nil:
+		cfg.RateLimitPolicies = *f.RateLimit.Policies
+		if err := cfg.RateLimitPolicies.Validate(); err != nil {
+			p.add("rate_limit.policies", EnvRateLimitPolicyFile, "%v", err)
+		}
+	default:
+		cfg.RateLimitPolicies = ratelimit.SinglePolicy(cfg.RateLimtPS)
+	}
+
+	return cfg
+}
+
+// WriteYAML writes the configuration in the config file format with its secrets redacted
+func (c *Config) WriteYAML(w io.Writer) error {
+	port, _ := strconv.Atoi(c.Port)
+	f := fileConfig{
+		Port:         port,
+		AppEnv:       c.AppEnv,
+		CursorSecret: redact(c.CursorSecret),
+		RateLimit: rateLimitSection{
+			RPS:        c.RateLimtPS,
+			MaxKeys:    c.RateLimitMaxKeys,
+			KeyTTL:     formatDuration(c.RateLimitKeyTTL),
+			Store:      c.RateLimitStore,
+			RedisURL:   redactURL(c.RedisURL),
+			PolicyFile: c.RateLimitPolicyFile,
+		},
+		JWT: jwtSection{
+			HS256Secret:        redact(c.JWTSecret),
+			RS256PublicKeyFile: c.JWTPublicKeyFile,
+			JWKSFile:           c.JWTJWKSFile,
+			Audience:           c.JWTAudience,
+		},
+		Auth: authSection{
+			AccessTokenTTL:  formatDuration(c.AccessTokenTTL),
+			RefreshTokenTTL: formatDuration(c.RefreshTokenTTL),
+			MaxFailedLogins: c.MaxFailedLogins,
+			LockoutDuration: formatDuration(c.LockoutDuration),
+		},
+		APIKeys: apiKeysSection{
+			TTL:                formatDuration(c.APIKeyTTL),
+			UsageFlushInterval: formatDuration(c.APIKeyUsageFlushInterval),
+		},
+		Quotas: quotasSection{
+			MonthlyRequests: c.QuotaMonthlyRequests,
+			DailyPosts:      c.QuotaDailyPosts,
+		},
+		LogLevel:       c.LogLevel,
+		CORS:           corsSection{AllowedOrigins: c.CORSAllowedOrigins},
+		TrustedProxies: c.TrustedProxies,
+		Database: databaseSection{
+			Driver:          c.DBDriver,
+			DSN:             redactURL(c.DBDSN),
+			MaxOpenConns:    c.DBMaxOpenConns,
+			MaxIdleConns:    c.DBMaxIdleConns,
+			ConnMaxLifetime: formatDuration(c.DBConnMaxLifetime),
+			ConnMaxIdleTime: formatDuration(c.DBConnMaxIdleTime),
+			BusyTimeout:     formatDuration(c.DBBusyTimeout),
+			JournalMode:     c.DBJournalMode,
+			Migrations:      c.DBMigrations,
+		},
+	}
+
+	// policies from a file are not repeated, and the single policy follows from rps
+	if c.RateLimitPolicyFile == "" && !reflect.DeepEqual(c.RateLimitPolicies, ratelimit.SinglePolicy(c.RateLimtPS)) {
+		f.RateLimit.Policies = &c.RateLimitPolicies
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return fmt.Errorf("error writing configuration: %w", err)
+	}
+	return enc.Close()
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactURL hides the password of a URL or key/value DSN, or the whole URL if it cannot be parsed
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") && dsnPassword.MatchString(raw) {
+		return dsnPassword.ReplaceAllString(raw, "${1}"+redacted)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	return u.Redacted()
+}
+
+// formatDuration drops the zero units time.Duration.String adds, so 2160h0m0s prints as 2160h
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// watchDebounce is how long the watched files must be quiet before a reload, saving a file
+// often shows up as several events
+const watchDebounce = 250 * time.Millisecond
+
+// reloads counts the reloads that were applied and the ones that failed validation
+var reloads = expvar.NewMap("config_reloads")
+
+// Store holds the running configuration. A reload swaps in a whole new Config, so readers see
+// either the old or the new one and never a mix of the two.
+//
+// Only the rate limits, the CORS origins and the log level change while running, see
+// withReloadable. Other settings are read once at startup and need a restart.
+type Store struct {
+	path    string
+	logger  *zap.Logger
+	current atomic.Pointer[Config]
+
+	// mu serialises reloads and guards onReload
+	mu       sync.Mutex
+	onReload []func(*Config)
+}
+
+// NewStore creates a Store holding cfg, path is the config file it was loaded from, if any
+func NewStore(cfg *Config, path string, logger *zap.Logger) *Store {
+	s := &Store{path: path, logger: logger}
+	s.current.Store(cfg)
+	return s
+}
+
+// Current returns the running configuration, callers must not modify it
+func (s *Store) Current() *Config {
+	return s.current.Load()
+}
+
+// OnReload registers fn to be called with the new configuration after every applied reload
+func (s *Store) OnReload(fn func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onReload = append(s.onReload, fn)
+}
+
+// Reload reads the config file and the environment again. The new configuration is validated
+// as a whole before anything is applied, an invalid one leaves the running one in place.
+// source says what triggered the reload and is only logged.
+func (s *Store) Reload(source string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logr := s.logger.With(zap.String("source", source))
+	old := s.current.Load()
+
+	next, err := load(s.path)
+	if err != nil {
+		reloads.Add("failed", 1)
+		logr.Error("Configuration reload failed, keeping the running configuration", zap.Error(err))
+		return err
+	}
+	// a generated cursor secret is kept, otherwise every reload would invalidate the cursors
+	if next.CursorSecret == "" {
+		next.CursorSecret = old.CursorSecret
+	}
+
+	applied := old.withReloadable(next)
+	if ignored := changedFields(applied, next); len(ignored) > 0 {
+		logr.Warn("Some changed settings only apply after a restart", zap.Strings("settings", ignored))
+	}
+
+	s.current.Store(applied)
+	for _, fn := range s.onReload {
+		fn(applied)
+	}
+
+	reloads.Add("applied", 1)
+	logr.Info("Configuration reloaded", zap.Strings("changed", changedFields(old, applied)))
+	return nil
+}
+
+// Watch reloads on every signal received from hup and whenever the config or policy file
+// changes, until ctx is done. Files are watched through their directory so that editors
+// which replace the file rather than write to it are noticed too.
+func (s *Store) Watch(ctx context.Context, hup <-chan os.Signal) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error watching config files: %w", err)
+	}
+	defer watcher.Close()
+
+	files := s.watch(watcher)
+	var settle <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			s.Reload("signal")
+			files = s.watch(watcher)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if files[filepath.Clean(event.Name)] && !event.Has(fsnotify.Chmod) {
+				settle = time.After(watchDebounce)
+			}
+		case <-settle:
+			settle = nil
+			s.Reload("file")
+			// the policy file may have moved to another directory
+			files = s.watch(watcher)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			s.logger.Warn("error watching config files", zap.Error(err))
+		}
+	}
+}
+
+// watch adds the directories of the config and policy files to the watcher and returns the files
+func (s *Store) watch(watcher *fsnotify.Watcher) map[string]bool {
+	files := make(map[string]bool)
+	for _, path := range []string{s.path, s.Current().RateLimitPolicyFile} {
+		if path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			s.logger.Warn("error watching config file", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		if err := watcher.Add(filepath.Dir(abs)); err != nil {
+			s.logger.Warn("error watching config file", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		files[abs] = true
+	}
+	return files
+}
+
+// withReloadable returns a copy of c with the settings that can change while running taken from next
+func (c Config) withReloadable(next *Config) *Config {
+	c.RateLimtPS = next.RateLimtPS
+	c.RateLimitPolicies = next.RateLimitPolicies
+	c.RateLimitPolicyFile = next.RateLimitPolicyFile
+	c.CORSAllowedOrigins = next.CORSAllowedOrigins
+	c.LogLevel = next.LogLevel
+	return &c
+}
+
+// changedFields returns the names of the fields that differ between a and b
+func changedFields(a, b *Config) []string {
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	var fields []string
+	for i := range va.NumField() {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			fields = append(fields, va.Type().Field(i).Name)
+		}
+	}
+	return fields
+}
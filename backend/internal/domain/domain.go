@@ -2,18 +2,53 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 //go:generate mockgen -destination=./mocks/user_mock.go -package=mocks github.com/victor-nach/postr-backend/internal/domain UserService
 type UserService interface {
 	Get(ctx context.Context, id string) (*User, error)
-	List(ctx context.Context, pageNumber int, pageSize int) (PaginatedUsers, error)
+	List(ctx context.Context, params ListUsersParams) (PaginatedUsers, error)
+	ListByCursor(ctx context.Context, params CursorParams) (PaginatedUsers, error)
 	Count(ctx context.Context) (int, error)
+	Create(ctx context.Context, user *User) error
+	Update(ctx context.Context, actor Principal, user *User) error
+	Delete(ctx context.Context, actor Principal, id string, opts DeleteUserOptions) error
 }
 
 //go:generate mockgen -destination=./mocks/post_mock.go -package=mocks github.com/victor-nach/postr-backend/internal/domain PostService
 type PostService interface {
-	Create(ctx context.Context, post *Post) error
-	List(ctx context.Context, userId string) ([]Post, error)
-	Delete(ctx context.Context, id string) error
+	Create(ctx context.Context, actor Principal, post *Post) error
+	Get(ctx context.Context, id string) (*PostDetail, error)
+	List(ctx context.Context, params ListPostsParams) (PaginatedPosts, error)
+	ListByCursor(ctx context.Context, userID string, params CursorParams) (PaginatedPosts, error)
+	Feed(ctx context.Context, params FeedParams) (PaginatedFeed, error)
+	Search(ctx context.Context, params SearchPostsParams) (PaginatedSearchResults, error)
+	Update(ctx context.Context, actor Principal, id string, update PostUpdate) (*Post, error)
+	ListRevisions(ctx context.Context, id string) ([]PostRevisionDetail, error)
+	Delete(ctx context.Context, actor Principal, id string) error
+}
+
+//go:generate mockgen -destination=./mocks/apikey_mock.go -package=mocks github.com/victor-nach/postr-backend/internal/domain APIKeyService
+type APIKeyService interface {
+	Create(ctx context.Context, params CreateAPIKeyParams) (*IssuedAPIKey, error)
+	List(ctx context.Context) ([]APIKey, error)
+	Revoke(ctx context.Context, id string) error
+	Rotate(ctx context.Context, id string, grace time.Duration) (*IssuedAPIKey, error)
+	Authenticate(ctx context.Context, rawKey string) (*APIKey, error)
+}
+
+//go:generate mockgen -destination=./mocks/auth_mock.go -package=mocks github.com/victor-nach/postr-backend/internal/domain AuthService
+type AuthService interface {
+	SetPassword(ctx context.Context, actor Principal, userID, password string) error
+	Login(ctx context.Context, username, password string) (*AuthTokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*AuthTokens, error)
+	Logout(ctx context.Context, refreshToken string) error
+}
+
+//go:generate mockgen -destination=./mocks/quota_mock.go -package=mocks github.com/victor-nach/postr-backend/internal/domain QuotaService
+type QuotaService interface {
+	Consume(ctx context.Context, keyID string, kind QuotaKind) (*QuotaUsage, error)
+	Refund(ctx context.Context, keyID string, kind QuotaKind, period string) error
+	Usage(ctx context.Context, keyID string) ([]QuotaUsage, error)
 }
@@ -63,12 +63,24 @@ var (
 		Message: "User not found",
 	}
 
+	ErrUserAlreadyExists = DomainError{
+		Status:  errorStatus,
+		Code:    "USR-409001",
+		Message: "User with the same username or email already exists",
+	}
+
 	ErrPostNotFound = DomainError{
 		Status:  errorStatus,
 		Code:    "PST-404001",
 		Message: "Post not found",
 	}
 
+	ErrPostForbidden = DomainError{
+		Status:  errorStatus,
+		Code:    "PST-403001",
+		Message: "Only the author or an admin can modify this post",
+	}
+
 	ErrMissingAPIKey = DomainError{
         Status:  errorStatus,
         Code:    "API-401001",
@@ -81,13 +93,87 @@ var (
         Message: "Invalid API key",
     }
 
+    ErrInvalidToken = DomainError{
+        Status:  errorStatus,
+        Code:    "API-401003",
+        Message: "Invalid bearer token",
+    }
+
+    ErrAPIKeyExpired = DomainError{
+        Status:  errorStatus,
+        Code:    "API-401004",
+        Message: "API key has expired",
+    }
+
+    ErrAPIKeyNotFound = DomainError{
+        Status:  errorStatus,
+        Code:    "API-404001",
+        Message: "API key not found",
+    }
+
+    ErrInsufficientScope = DomainError{
+        Status:  errorStatus,
+        Code:    "API-403001",
+        Message: "API key is missing the required scope",
+    }
+
+    ErrPasswordForbidden = DomainError{
+        Status:  errorStatus,
+        Code:    "USR-403001",
+        Message: "Only the user or an admin can set this password",
+    }
+
+    ErrUserForbidden = DomainError{
+        Status:  errorStatus,
+        Code:    "USR-403002",
+        Message: "Only the user or an admin can modify this user",
+    }
+
+    ErrReassignForbidden = DomainError{
+        Status:  errorStatus,
+        Code:    "USR-403003",
+        Message: "Only an admin can reassign posts to another user",
+    }
+
+    ErrInvalidCredentials = DomainError{
+        Status:  errorStatus,
+        Code:    "AUTH-401001",
+        Message: "Invalid username or password",
+    }
+
+    ErrInvalidRefreshToken = DomainError{
+        Status:  errorStatus,
+        Code:    "AUTH-401002",
+        Message: "Invalid or expired refresh token",
+    }
+
     ErrTooManyRequests = DomainError{
         Status:  errorStatus,
         Code:    "APP-429001",
         Message: "Too many requests",
     }
+
+    ErrQuotaExceeded = DomainError{
+        Status:  errorStatus,
+        Code:    "APP-429002",
+        Message: "Quota exceeded",
+    }
 )
 
+// ErrInsufficientScopeFor names the scope the API key is missing
+func ErrInsufficientScopeFor(scope Scope) DomainError {
+	err := ErrInsufficientScope
+	err.Message = fmt.Sprintf("%s: %s", err.Message, scope)
+	return err
+}
+
+// ErrQuotaExceededFor names the quota the API key has used up
+func ErrQuotaExceededFor(kind QuotaKind) DomainError {
+	err := ErrQuotaExceeded
+	err.Message = fmt.Sprintf("%s: %s", err.Message, kind.Description())
+	return err
+}
+
 func ErrInvalidInputWithStr(message string) DomainError {
 	err := ErrInvalidInput
 	err.Message = fmt.Sprintf("%s: %s", err.Message, message)
@@ -42,44 +42,134 @@ func (m *MockPostService) EXPECT() *MockPostServiceMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockPostService) Create(ctx context.Context, post *domain.Post) error {
+func (m *MockPostService) Create(ctx context.Context, actor domain.Principal, post *domain.Post) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, post)
+	ret := m.ctrl.Call(m, "Create", ctx, actor, post)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockPostServiceMockRecorder) Create(ctx, post any) *gomock.Call {
+func (mr *MockPostServiceMockRecorder) Create(ctx, actor, post any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPostService)(nil).Create), ctx, post)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPostService)(nil).Create), ctx, actor, post)
 }
 
 // Delete mocks base method.
-func (m *MockPostService) Delete(ctx context.Context, id string) error {
+func (m *MockPostService) Delete(ctx context.Context, actor domain.Principal, id string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret := m.ctrl.Call(m, "Delete", ctx, actor, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockPostServiceMockRecorder) Delete(ctx, id any) *gomock.Call {
+func (mr *MockPostServiceMockRecorder) Delete(ctx, actor, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPostService)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPostService)(nil).Delete), ctx, actor, id)
+}
+
+// Feed mocks base method.
+func (m *MockPostService) Feed(ctx context.Context, params domain.FeedParams) (domain.PaginatedFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Feed", ctx, params)
+	ret0, _ := ret[0].(domain.PaginatedFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Feed indicates an expected call of Feed.
+func (mr *MockPostServiceMockRecorder) Feed(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Feed", reflect.TypeOf((*MockPostService)(nil).Feed), ctx, params)
+}
+
+// Get mocks base method.
+func (m *MockPostService) Get(ctx context.Context, id string) (*domain.PostDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*domain.PostDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPostServiceMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPostService)(nil).Get), ctx, id)
 }
 
 // List mocks base method.
-func (m *MockPostService) List(ctx context.Context, userId string) ([]domain.Post, error) {
+func (m *MockPostService) List(ctx context.Context, params domain.ListPostsParams) (domain.PaginatedPosts, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", ctx, userId)
-	ret0, _ := ret[0].([]domain.Post)
+	ret := m.ctrl.Call(m, "List", ctx, params)
+	ret0, _ := ret[0].(domain.PaginatedPosts)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockPostServiceMockRecorder) List(ctx, userId any) *gomock.Call {
+func (mr *MockPostServiceMockRecorder) List(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPostService)(nil).List), ctx, params)
+}
+
+// ListByCursor mocks base method.
+func (m *MockPostService) ListByCursor(ctx context.Context, userID string, params domain.CursorParams) (domain.PaginatedPosts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByCursor", ctx, userID, params)
+	ret0, _ := ret[0].(domain.PaginatedPosts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByCursor indicates an expected call of ListByCursor.
+func (mr *MockPostServiceMockRecorder) ListByCursor(ctx, userID, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByCursor", reflect.TypeOf((*MockPostService)(nil).ListByCursor), ctx, userID, params)
+}
+
+// ListRevisions mocks base method.
+func (m *MockPostService) ListRevisions(ctx context.Context, id string) ([]domain.PostRevisionDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", ctx, id)
+	ret0, _ := ret[0].([]domain.PostRevisionDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockPostServiceMockRecorder) ListRevisions(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockPostService)(nil).ListRevisions), ctx, id)
+}
+
+// Search mocks base method.
+func (m *MockPostService) Search(ctx context.Context, params domain.SearchPostsParams) (domain.PaginatedSearchResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, params)
+	ret0, _ := ret[0].(domain.PaginatedSearchResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockPostServiceMockRecorder) Search(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockPostService)(nil).Search), ctx, params)
+}
+
+// Update mocks base method.
+func (m *MockPostService) Update(ctx context.Context, actor domain.Principal, id string, update domain.PostUpdate) (*domain.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, actor, id, update)
+	ret0, _ := ret[0].(*domain.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockPostServiceMockRecorder) Update(ctx, actor, id, update any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPostService)(nil).List), ctx, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPostService)(nil).Update), ctx, actor, id, update)
 }
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/victor-nach/postr-backend/internal/domain (interfaces: QuotaService)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/quota_mock.go -package=mocks github.com/victor-nach/postr-backend/internal/domain QuotaService
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/victor-nach/postr-backend/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockQuotaService is a mock of QuotaService interface.
+type MockQuotaService struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuotaServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockQuotaServiceMockRecorder is the mock recorder for MockQuotaService.
+type MockQuotaServiceMockRecorder struct {
+	mock *MockQuotaService
+}
+
+// NewMockQuotaService creates a new mock instance.
+func NewMockQuotaService(ctrl *gomock.Controller) *MockQuotaService {
+	mock := &MockQuotaService{ctrl: ctrl}
+	mock.recorder = &MockQuotaServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuotaService) EXPECT() *MockQuotaServiceMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockQuotaService) Consume(ctx context.Context, keyID string, kind domain.QuotaKind) (*domain.QuotaUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, keyID, kind)
+	ret0, _ := ret[0].(*domain.QuotaUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockQuotaServiceMockRecorder) Consume(ctx, keyID, kind any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockQuotaService)(nil).Consume), ctx, keyID, kind)
+}
+
+// Refund mocks base method.
+func (m *MockQuotaService) Refund(ctx context.Context, keyID string, kind domain.QuotaKind, period string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, keyID, kind, period)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockQuotaServiceMockRecorder) Refund(ctx, keyID, kind, period any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockQuotaService)(nil).Refund), ctx, keyID, kind, period)
+}
+
+// Usage mocks base method.
+func (m *MockQuotaService) Usage(ctx context.Context, keyID string) ([]domain.QuotaUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage", ctx, keyID)
+	ret0, _ := ret[0].([]domain.QuotaUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockQuotaServiceMockRecorder) Usage(ctx, keyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockQuotaService)(nil).Usage), ctx, keyID)
+}
@@ -70,6 +70,20 @@ func (mr *MockUserServiceMockRecorder) Create(ctx, user any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserService)(nil).Create), ctx, user)
 }
 
+// Delete mocks base method.
+func (m *MockUserService) Delete(ctx context.Context, actor domain.Principal, id string, opts domain.DeleteUserOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, actor, id, opts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserServiceMockRecorder) Delete(ctx, actor, id, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserService)(nil).Delete), ctx, actor, id, opts)
+}
+
 // Get mocks base method.
 func (m *MockUserService) Get(ctx context.Context, id string) (*domain.User, error) {
 	m.ctrl.T.Helper()
@@ -86,16 +100,45 @@ func (mr *MockUserServiceMockRecorder) Get(ctx, id any) *gomock.Call {
 }
 
 // List mocks base method.
-func (m *MockUserService) List(ctx context.Context, pageNumber, pageSize int) (domain.PaginatedUsers, error) {
+func (m *MockUserService) List(ctx context.Context, params domain.ListUsersParams) (domain.PaginatedUsers, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", ctx, pageNumber, pageSize)
+	ret := m.ctrl.Call(m, "List", ctx, params)
 	ret0, _ := ret[0].(domain.PaginatedUsers)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockUserServiceMockRecorder) List(ctx, pageNumber, pageSize any) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) List(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserService)(nil).List), ctx, params)
+}
+
+// ListByCursor mocks base method.
+func (m *MockUserService) ListByCursor(ctx context.Context, params domain.CursorParams) (domain.PaginatedUsers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByCursor", ctx, params)
+	ret0, _ := ret[0].(domain.PaginatedUsers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByCursor indicates an expected call of ListByCursor.
+func (mr *MockUserServiceMockRecorder) ListByCursor(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByCursor", reflect.TypeOf((*MockUserService)(nil).ListByCursor), ctx, params)
+}
+
+// Update mocks base method.
+func (m *MockUserService) Update(ctx context.Context, actor domain.Principal, user *domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, actor, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUserServiceMockRecorder) Update(ctx, actor, user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserService)(nil).List), ctx, pageNumber, pageSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserService)(nil).Update), ctx, actor, user)
 }
@@ -1,12 +1,21 @@
 package domain
 
+import (
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
 type User struct {
-	ID       string  `json:"id"`
-	Name     string  `json:"name"`
-	Username string  `json:"username"`
-	Email    string  `json:"email"`
-	Phone    string  `json:"phone"`
-	Address  Address `json:"address"`
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Username  string  `json:"username"`
+	Email     string  `json:"email"`
+	Phone     string  `json:"phone"`
+	Address   Address `json:"address"`
+	CreatedAt string  `json:"created_at"`
 }
 
 type Address struct {
@@ -24,16 +33,390 @@ type Post struct {
 	Title     string `json:"title"`
 	Body      string `json:"body"`
 	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// PostUpdate holds the fields to change on a post, nil fields are left unchanged
+type PostUpdate struct {
+	Title     *string
+	Body      *string
+	UpdatedAt string
+}
+
+// PostRevision is a previous version of a post, versions are numbered from 1
+type PostRevision struct {
+	PostID    string `json:"post_id"`
+	Version   int    `json:"version"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+}
+
+// PostRevisionDetail is a revision along with the changes made by the version that replaced it
+type PostRevisionDetail struct {
+	PostRevision
+	Changes PostChanges `json:"changes"`
+}
+
+type PostChanges struct {
+	Title []DiffLine `json:"title"`
+	Body  []DiffLine `json:"body"`
+}
+
+// DiffLine is a single line of a line based diff
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// PostDetail is a post together with a summary of its author
+type PostDetail struct {
+	Post
+	Author Author `json:"author"`
+}
+
+// Author is the public summary of the user who wrote a post
+type Author struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+// Role is the access level of an authenticated principal
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+// Principal is the authenticated caller a request is made on behalf of
+type Principal struct {
+	UserID string
+	Role   Role
+}
+
+// CanModify reports whether the principal may change resources owned by ownerID
+func (p Principal) CanModify(ownerID string) bool {
+	return p.Role == RoleAdmin || (p.UserID != "" && p.UserID == ownerID)
+}
+
+// Scope grants an API key access to a group of routes
+type Scope string
+
+const (
+	ScopeUsersRead  Scope = "users:read"
+	ScopeUsersWrite Scope = "users:write"
+	ScopePostsRead  Scope = "posts:read"
+	ScopePostsWrite Scope = "posts:write"
+	// ScopeAdmin grants every other scope and the admin role
+	ScopeAdmin Scope = "admin"
+)
+
+// AllScopes lists every known scope
+var AllScopes = []Scope{ScopeUsersRead, ScopeUsersWrite, ScopePostsRead, ScopePostsWrite, ScopeAdmin}
+
+// UserScopes is the read and write access of a regular user
+var UserScopes = Scopes{ScopeUsersRead, ScopeUsersWrite, ScopePostsRead, ScopePostsWrite}
+
+// Scopes is the set of scopes held by a key, stored as a space separated list
+type Scopes []Scope
+
+// Allows reports whether the scopes grant the given scope
+func (s Scopes) Allows(scope Scope) bool {
+	return slices.Contains(s, scope) || slices.Contains(s, ScopeAdmin)
+}
+
+// Role is the role implied by the scopes
+func (s Scopes) Role() Role {
+	if slices.Contains(s, ScopeAdmin) {
+		return RoleAdmin
+	}
+	return RoleUser
+}
+
+// String returns the scopes space separated, as they are stored
+func (s Scopes) String() string {
+	parts := make([]string, len(s))
+	for i, scope := range s {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (s Scopes) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+func (s *Scopes) Scan(value any) error {
+	var raw string
+	switch v := value.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	case nil:
+	default:
+		return fmt.Errorf("unsupported scopes value %T", value)
+	}
+
+	*s = Scopes{}
+	for _, part := range strings.Fields(raw) {
+		*s = append(*s, Scope(part))
+	}
+	return nil
+}
+
+// APIKey is an issued API key, only a salted hash of its secret is stored.
+// Keys without an ExpiresAt never expire.
+type APIKey struct {
+	ID         string  `json:"id"`
+	OwnerID    string  `json:"owner_id"`
+	Name       string  `json:"name"`
+	Scopes     Scopes  `json:"scopes"`
+	Prefix     string  `json:"prefix"`
+	Salt       string  `json:"-"`
+	Hash       string  `json:"-"`
+	CreatedAt  string  `json:"created_at"`
+	ExpiresAt  *string `json:"expires_at,omitempty"`
+	RevokedAt  *string `json:"revoked_at,omitempty"`
+	LastUsedAt *string `json:"last_used_at,omitempty"`
+	LastUsedIP *string `json:"last_used_ip,omitempty"`
+}
+
+// Expired reports whether the key has expired by the given time
+func (k APIKey) Expired(now time.Time) bool {
+	if k.ExpiresAt == nil {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, *k.ExpiresAt)
+	return err != nil || !now.Before(expiresAt)
+}
+
+// APIKeyUsage is the last use of a key, recorded after the request
+type APIKeyUsage struct {
+	KeyID      string
+	LastUsedAt string
+	LastUsedIP string
+}
+
+// IssuedAPIKey is a newly created API key along with its plaintext value, which is only returned once
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyParams describes a key to issue, OwnerID is the user requests are made on behalf of.
+// Keys without an ExpiresAt get the service's default lifetime.
+type CreateAPIKeyParams struct {
+	OwnerID   string
+	Name      string
+	Scopes    Scopes
+	ExpiresAt *time.Time
+}
+
+// QuotaKind is something an API key has a budget of per period
+type QuotaKind string
+
+const (
+	// QuotaRequests counts every request made with the key, per calendar month
+	QuotaRequests QuotaKind = "requests"
+	// QuotaPosts counts the posts created with the key, per day
+	QuotaPosts QuotaKind = "posts"
+)
+
+// Description names the quota in error messages
+func (k QuotaKind) Description() string {
+	switch k {
+	case QuotaRequests:
+		return "monthly requests"
+	case QuotaPosts:
+		return "daily posts"
+	}
+	return string(k)
+}
+
+// QuotaUsage is how much of a quota a key has used in the current period, periods are in UTC
+type QuotaUsage struct {
+	Kind      QuotaKind `json:"kind"`
+	Period    string    `json:"period"`
+	Used      int       `json:"used"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetsAt  string    `json:"resets_at"`
+}
+
+// Credentials is a user's password login, only a bcrypt hash of the password is stored
+type Credentials struct {
+	UserID         string  `json:"user_id"`
+	PasswordHash   string  `json:"-"`
+	FailedAttempts int     `json:"failed_attempts"`
+	LockedUntil    *string `json:"locked_until,omitempty"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+// RefreshToken is one token in a login session, only a hash of the token is stored
+type RefreshToken struct {
+	ID        string  `json:"id"`
+	SessionID string  `json:"session_id"`
+	UserID    string  `json:"user_id"`
+	TokenHash string  `json:"-"`
+	CreatedAt string  `json:"created_at"`
+	ExpiresAt string  `json:"expires_at"`
+	UsedAt    *string `json:"used_at,omitempty"`
+	RevokedAt *string `json:"revoked_at,omitempty"`
+}
+
+// AuthTokens are issued on login and refresh, the refresh token can only be used once
+type AuthTokens struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt string `json:"refresh_expires_at"`
+}
+
+// UserDeleteMode controls what happens to a user's posts when the user is deleted
+type UserDeleteMode string
+
+const (
+	// UserDeleteCascade removes the user's posts along with the user
+	UserDeleteCascade UserDeleteMode = "cascade"
+	// UserDeleteReassign moves the user's posts to another user before deleting
+	UserDeleteReassign UserDeleteMode = "reassign"
+)
+
+type DeleteUserOptions struct {
+	Mode       UserDeleteMode
+	ReassignTo string
 }
 
 type PaginatedUsers struct {
 	Pagination Pagination `json:"pagination"`
 	Users      []User     `json:"users"`
+	NextCursor *Cursor    `json:"-"`
+	PrevCursor *Cursor    `json:"-"`
+}
+
+type PaginatedPosts struct {
+	Pagination Pagination `json:"pagination"`
+	Posts      []Post     `json:"posts"`
+	NextCursor *Cursor    `json:"-"`
+	PrevCursor *Cursor    `json:"-"`
+}
+
+// ListUsersParams filters, sorts and pages a user listing, empty filters match every user
+type ListUsersParams struct {
+	Query      string
+	City       string
+	State      string
+	PageNumber int
+	PageSize   int
+	Sort       string
+	Order      SortOrder
+}
+
+// Sortable user fields
+const (
+	UserSortCreatedAt = "created_at"
+	UserSortName      = "name"
+	UserSortUsername  = "username"
+	UserSortEmail     = "email"
+)
+
+// ListPostsParams filters, sorts and pages a post listing
+type ListPostsParams struct {
+	UserID     string
+	PageNumber int
+	PageSize   int
+	Sort       string
+	Order      SortOrder
+}
+
+// FeedParams pages the global feed, Since and Until are optional RFC3339 bounds on created_at
+type FeedParams struct {
+	Since      string
+	Until      string
+	PageNumber int
+	PageSize   int
+}
+
+// PaginatedFeed is a page of posts across all users, newest first
+type PaginatedFeed struct {
+	Pagination Pagination   `json:"pagination"`
+	Posts      []PostDetail `json:"posts"`
+}
+
+// SearchPostsParams pages the results of a full-text search over posts
+type SearchPostsParams struct {
+	Query      string
+	PageNumber int
+	PageSize   int
+}
+
+// PostSearchResult is a post matching a search, Snippet is an excerpt with the matched
+// terms wrapped in <mark> tags
+type PostSearchResult struct {
+	PostDetail
+	Snippet string `json:"snippet"`
+}
+
+// PaginatedSearchResults is a page of search results, best match first
+type PaginatedSearchResults struct {
+	Pagination Pagination         `json:"pagination"`
+	Posts      []PostSearchResult `json:"posts"`
+}
+
+// Sortable post fields
+const (
+	PostSortCreatedAt = "created_at"
+	PostSortUpdatedAt = "updated_at"
+	PostSortTitle     = "title"
+)
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// Cursor marks a position in a keyset paginated listing ordered by (created_at, id), newest first
+type Cursor struct {
+	CreatedAt string          `json:"created_at"`
+	ID        string          `json:"id"`
+	Direction CursorDirection `json:"direction"`
+}
+
+type CursorDirection string
+
+const (
+	// CursorNext pages towards older rows
+	CursorNext CursorDirection = "next"
+	// CursorPrev pages towards newer rows
+	CursorPrev CursorDirection = "prev"
+)
+
+// CursorParams pages a listing by cursor, a nil Cursor starts at the newest row
+type CursorParams struct {
+	Cursor *Cursor
+	Limit  int
 }
 
 // Pagination details.
+// In cursor mode CurrentPage is 0 and NextCursor/PrevCursor are set when more rows exist.
 type Pagination struct {
-	CurrentPage int `json:"current_page"`
-	TotalPages  int `json:"total_pages"`
-	TotalSize   int `json:"total_size"`
+	CurrentPage int    `json:"current_page"`
+	TotalPages  int    `json:"total_pages"`
+	TotalSize   int    `json:"total_size"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	PrevCursor  string `json:"prev_cursor,omitempty"`
 }
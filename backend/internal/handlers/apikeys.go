@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+type APIKeyHandler struct {
+	service domain.APIKeyService
+	logger  *zap.Logger
+}
+
+func NewAPIKeyHandler(service domain.APIKeyService, logger *zap.Logger) *APIKeyHandler {
+	logger = logger.With(zap.String("package", "handlers"))
+
+	return &APIKeyHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateAPIKey issues a new key, the response is the only time the plaintext key is shown
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "CreateAPIKey"))
+
+	req, err := h.validateCreateAPIKey(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	scopes := make(domain.Scopes, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = domain.Scope(scope)
+	}
+
+	params := domain.CreateAPIKeyParams{
+		OwnerID: req.OwnerID,
+		Name:    req.Name,
+		Scopes:  scopes,
+	}
+	if req.ExpiresAt != "" {
+		expiresAt, _ := time.Parse(time.RFC3339, req.ExpiresAt)
+		params.ExpiresAt = &expiresAt
+	}
+
+	issued, err := h.service.Create(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("API key created successfully", zap.String("id", issued.ID))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "API key created successfully",
+		Data:    issued,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "ListAPIKeys"))
+
+	keys, err := h.service.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("API keys listed successfully", zap.Int("count", len(keys)))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "API keys listed successfully",
+		Data:    keys,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "RevokeAPIKey"))
+
+	id, err := h.validateAPIKeyID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("API key revoked successfully", zap.String("id", id))
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RotateAPIKey returns a replacement key with the same scopes, the old key is revoked or,
+// with a grace period, keeps working until the period ends
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "RotateAPIKey"))
+
+	id, grace, err := h.validateRotateAPIKey(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	issued, err := h.service.Rotate(c.Request.Context(), id, grace)
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("API key rotated successfully", zap.String("id", id), zap.String("replacement_id", issued.ID))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "API key rotated successfully",
+		Data:    issued,
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+type AuthHandler struct {
+	service domain.AuthService
+	logger  *zap.Logger
+}
+
+func NewAuthHandler(service domain.AuthService, logger *zap.Logger) *AuthHandler {
+	logger = logger.With(zap.String("package", "handlers"))
+
+	return &AuthHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Login exchanges a username and password for an access token and a refresh token
+func (h *AuthHandler) Login(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "Login"))
+
+	req, err := h.validateLogin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	tokens, err := h.service.Login(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, err)
+		default:
+			c.JSON(http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	logr.Info("User logged in successfully", zap.String("username", req.Username))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "Logged in successfully",
+		Data:    tokens,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Refresh exchanges a refresh token for new tokens, the old refresh token stops working
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "Refresh"))
+
+	req, err := h.validateRefreshToken(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	tokens, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("Tokens refreshed successfully")
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "Tokens refreshed successfully",
+		Data:    tokens,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "Logout"))
+
+	req, err := h.validateRefreshToken(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("User logged out successfully")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// SetPassword sets the password a user logs in with, only the user or an admin may set it
+func (h *AuthHandler) SetPassword(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "SetPassword"))
+
+	principal, ok := principalFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, domain.ErrMissingAPIKey)
+		return
+	}
+
+	id, req, err := h.validateSetPassword(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.SetPassword(c.Request.Context(), principal, id, req.Password); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPasswordForbidden):
+			c.JSON(http.StatusForbidden, err)
+		case errors.Is(err, domain.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, err)
+		default:
+			c.JSON(http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	logr.Info("Password set successfully", zap.String("user_id", id))
+	c.JSON(http.StatusNoContent, nil)
+}
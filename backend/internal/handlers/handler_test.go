@@ -17,15 +17,21 @@ import (
 
 	"github.com/victor-nach/postr-backend/internal/domain"
 	"github.com/victor-nach/postr-backend/internal/domain/mocks"
+	"github.com/victor-nach/postr-backend/internal/middlewares"
+	"github.com/victor-nach/postr-backend/internal/services/usersservice"
+	repomocks "github.com/victor-nach/postr-backend/internal/services/usersservice/mocks"
+	"github.com/victor-nach/postr-backend/pkg/cursor"
 )
 
+var testCursors = cursor.NewCodec([]byte("test-secret"))
+
 func TestPostHandler_CreatePost(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockPostService := mocks.NewMockPostService(ctrl)
 	logger := zap.NewNop()
-	handler := NewPostHandler(mockPostService, logger)
+	handler := NewPostHandler(mockPostService, testCursors, logger)
 
 	reqBody := `{"userId": "b63df5729bd14a4f9f0d2a8155a81fde", "title": "Test Title", "body": "Test Body"}`
 	req, err := http.NewRequest("POST", "/posts", strings.NewReader(reqBody))
@@ -35,9 +41,11 @@ func TestPostHandler_CreatePost(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
+	c.Set(middlewares.UserIDKey, "b63df5729bd14a4f9f0d2a8155a81fde")
 
-	mockPostService.EXPECT().Create(gomock.Any(), gomock.Any()).
-		DoAndReturn(func(ctx context.Context, post *domain.Post) error {
+	principal := domain.Principal{UserID: "b63df5729bd14a4f9f0d2a8155a81fde", Role: domain.RoleUser}
+	mockPostService.EXPECT().Create(gomock.Any(), principal, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, actor domain.Principal, post *domain.Post) error {
 			require.Equal(t, "b63df5729bd14a4f9f0d2a8155a81fde", post.UserID)
 			require.Equal(t, "Test Title", post.Title)
 			require.Equal(t, "Test Body", post.Body)
@@ -68,7 +76,7 @@ func TestPostHandler_ListPostsByUserID(t *testing.T) {
 
 	mockPostService := mocks.NewMockPostService(ctrl)
 	logger := zap.NewNop()
-	handler := NewPostHandler(mockPostService, logger)
+	handler := NewPostHandler(mockPostService, testCursors, logger)
 
 	userId := newUUID()
 	req, err := http.NewRequest("GET", fmt.Sprintf("/posts?userId=%s", userId), nil)
@@ -96,7 +104,12 @@ func TestPostHandler_ListPostsByUserID(t *testing.T) {
 		},
 	}
 
-	mockPostService.EXPECT().List(gomock.Any(), userId).Return(expectedPosts, nil).Times(1)
+	params := domain.ListPostsParams{UserID: userId, PageNumber: 1, PageSize: 10, Sort: domain.PostSortCreatedAt, Order: domain.SortDesc}
+	paginatedPosts := domain.PaginatedPosts{
+		Pagination: domain.Pagination{CurrentPage: 1, TotalPages: 1, TotalSize: 2},
+		Posts:      expectedPosts,
+	}
+	mockPostService.EXPECT().List(gomock.Any(), params).Return(paginatedPosts, nil).Times(1)
 
 	handler.ListPostsByUserID(c)
 
@@ -107,19 +120,379 @@ func TestPostHandler_ListPostsByUserID(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "success", resp.Status)
 	require.Equal(t, "Posts listed successfully", resp.Message)
+	require.NotNil(t, resp.Pagination)
 
 	dataSlice, ok := resp.Data.([]interface{})
 	require.True(t, ok, "expected Data to be a slice")
 	require.Len(t, dataSlice, len(expectedPosts))
 }
 
+func TestPostHandler_CreatePost_DefaultsToPrincipal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	principalID := newUUID()
+	req, err := http.NewRequest("POST", "/posts", strings.NewReader(`{"title": "Test Title", "body": "Test Body"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middlewares.UserIDKey, principalID)
+
+	mockPostService.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, actor domain.Principal, post *domain.Post) error {
+			require.Equal(t, principalID, post.UserID)
+			return nil
+		}).Times(1)
+
+	handler.CreatePost(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPostHandler_CreatePost_Forbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	reqBody := `{"userId": "b63df5729bd14a4f9f0d2a8155a81fde", "title": "Test Title", "body": "Test Body"}`
+	req, err := http.NewRequest("POST", "/posts", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middlewares.UserIDKey, newUUID())
+
+	mockPostService.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(domain.ErrPostForbidden).Times(1)
+
+	handler.CreatePost(c)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	var resp domain.DomainError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, domain.ErrPostForbidden.Code, resp.Code)
+}
+
+func TestPostHandler_CreatePost_Unauthenticated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	req, err := http.NewRequest("POST", "/posts", strings.NewReader(`{"title": "Test Title", "body": "Test Body"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.CreatePost(c)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPostHandler_GetPostByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	postID, userID := newUUID(), newUUID()
+	req, err := http.NewRequest("GET", "/posts/"+postID, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: postID}}
+
+	detail := &domain.PostDetail{
+		Post:   domain.Post{ID: postID, UserID: userID, Title: "Title", Body: "Body"},
+		Author: domain.Author{ID: userID, Name: "Jane Doe", Username: "janedoe"},
+	}
+	mockPostService.EXPECT().Get(gomock.Any(), postID).Return(detail, nil).Times(1)
+
+	handler.GetPostByID(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp APIResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, "Post retrieved successfully", resp.Message)
+
+	data, ok := resp.Data.(map[string]interface{})
+	require.True(t, ok)
+	require.Equal(t, postID, data["id"])
+	author, ok := data["author"].(map[string]interface{})
+	require.True(t, ok)
+	require.Equal(t, "janedoe", author["username"])
+}
+
+func TestPostHandler_GetPostByID_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	postID := newUUID()
+	req, err := http.NewRequest("GET", "/posts/"+postID, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: postID}}
+
+	mockPostService.EXPECT().Get(gomock.Any(), postID).Return(nil, domain.ErrPostNotFound).Times(1)
+
+	handler.GetPostByID(c)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPostHandler_UpdatePost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	postID := newUUID()
+	req, err := http.NewRequest("PATCH", "/posts/"+postID, strings.NewReader(`{"title": "New Title"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: postID}}
+	c.Set(middlewares.UserIDKey, "owner")
+
+	mockPostService.EXPECT().Update(gomock.Any(), domain.Principal{UserID: "owner", Role: domain.RoleUser}, postID, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, actor domain.Principal, id string, update domain.PostUpdate) (*domain.Post, error) {
+			require.NotNil(t, update.Title)
+			require.Equal(t, "New Title", *update.Title)
+			require.Nil(t, update.Body)
+			require.NotEmpty(t, update.UpdatedAt)
+			return &domain.Post{ID: id, Title: *update.Title, Body: "Body", UpdatedAt: update.UpdatedAt}, nil
+		}).Times(1)
+
+	handler.UpdatePost(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp APIResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, "Post updated successfully", resp.Message)
+}
+
+func TestPostHandler_UpdatePost_EmptyBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	postID := newUUID()
+	req, err := http.NewRequest("PATCH", "/posts/"+postID, strings.NewReader(`{}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: postID}}
+	c.Set(middlewares.UserIDKey, "owner")
+
+	handler.UpdatePost(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPostHandler_ListPostsByUserID_InvalidSort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("/posts?userId=%s&sort=body&order=up", newUUID()), nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.ListPostsByUserID(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp domain.DomainError
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Contains(t, resp.FieldErrors, "sort")
+	require.Contains(t, resp.FieldErrors, "order")
+}
+
+func TestPostHandler_Feed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	req, err := http.NewRequest("GET", "/feed?pageSize=5&since=2025-01-01T00:00:00Z", nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	params := domain.FeedParams{Since: "2025-01-01T00:00:00Z", PageNumber: 1, PageSize: 5}
+	feed := domain.PaginatedFeed{
+		Pagination: domain.Pagination{CurrentPage: 1, TotalPages: 1, TotalSize: 1},
+		Posts: []domain.PostDetail{
+			{
+				Post:   domain.Post{ID: newUUID(), Title: "Title 1"},
+				Author: domain.Author{ID: newUUID(), Name: "Jane Doe", Username: "janedoe"},
+			},
+		},
+	}
+	mockPostService.EXPECT().Feed(gomock.Any(), params).Return(feed, nil).Times(1)
+
+	handler.Feed(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp APIResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, "Feed listed successfully", resp.Message)
+	require.NotNil(t, resp.Pagination)
+
+	dataSlice, ok := resp.Data.([]interface{})
+	require.True(t, ok, "expected Data to be a slice")
+	require.Len(t, dataSlice, 1)
+}
+
+func TestPostHandler_Feed_InvalidRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	for _, query := range []string{
+		"since=yesterday",
+		"since=2025-02-01T00:00:00Z&until=2025-01-01T00:00:00Z",
+	} {
+		req, err := http.NewRequest("GET", "/feed?"+query, nil)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.Feed(c)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestPostHandler_SearchPosts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	req, err := http.NewRequest("GET", "/posts/search?q=+zebra+crossing+&pageNumber=2", nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	params := domain.SearchPostsParams{Query: "zebra crossing", PageNumber: 2, PageSize: 10}
+	results := domain.PaginatedSearchResults{
+		Pagination: domain.Pagination{CurrentPage: 2, TotalPages: 2, TotalSize: 11},
+		Posts: []domain.PostSearchResult{
+			{
+				PostDetail: domain.PostDetail{Post: domain.Post{ID: newUUID(), Title: "Zebra crossings"}},
+				Snippet:    "<mark>Zebra</mark> <mark>crossings</mark>",
+			},
+		},
+	}
+	mockPostService.EXPECT().Search(gomock.Any(), params).Return(results, nil).Times(1)
+
+	handler.SearchPosts(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp APIResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, "Posts searched successfully", resp.Message)
+
+	dataSlice, ok := resp.Data.([]interface{})
+	require.True(t, ok, "expected Data to be a slice")
+	require.Len(t, dataSlice, 1)
+}
+
+func TestPostHandler_SearchPosts_MissingQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostService := mocks.NewMockPostService(ctrl)
+	logger := zap.NewNop()
+	handler := NewPostHandler(mockPostService, testCursors, logger)
+
+	req, err := http.NewRequest("GET", "/posts/search?q=+", nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.SearchPosts(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestPostHandler_DeletePost(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockPostService := mocks.NewMockPostService(ctrl)
 	logger := zap.NewNop()
-	handler := NewPostHandler(mockPostService, logger)
+	handler := NewPostHandler(mockPostService, testCursors, logger)
 
 	postID := newUUID()
 
@@ -133,8 +506,10 @@ func TestPostHandler_DeletePost(t *testing.T) {
 	c.Params = gin.Params{
 		{Key: "id", Value: postID},
 	}
+	c.Set(middlewares.UserIDKey, "admin")
+	c.Set(middlewares.RoleKey, string(domain.RoleAdmin))
 
-	mockPostService.EXPECT().Delete(gomock.Any(), postID).Return(nil).Times(1)
+	mockPostService.EXPECT().Delete(gomock.Any(), domain.Principal{UserID: "admin", Role: domain.RoleAdmin}, postID).Return(nil).Times(1)
 
 	handler.DeletePost(c)
 
@@ -148,7 +523,7 @@ func TestUserHandler_ListUsers(t *testing.T) {
 
 	mockUserService := mocks.NewMockUserService(ctrl)
 	logger := zap.NewNop()
-	handler := NewUserHandler(mockUserService, logger)
+	handler := NewUserHandler(mockUserService, testCursors, logger)
 
 	req, err := http.NewRequest("GET", "/users?pageNumber=1&pageSize=10", nil)
 	require.NoError(t, err)
@@ -166,7 +541,8 @@ func TestUserHandler_ListUsers(t *testing.T) {
 			{ID: newUUID()},
 		},
 	}
-	mockUserService.EXPECT().List(gomock.Any(), 1, 10).Return(paginatedUsers, nil).Times(1)
+	params := domain.ListUsersParams{PageNumber: 1, PageSize: 10, Sort: domain.UserSortCreatedAt, Order: domain.SortDesc}
+	mockUserService.EXPECT().List(gomock.Any(), params).Return(paginatedUsers, nil).Times(1)
 
 	handler.ListUsers(c)
 	require.Equal(t, http.StatusOK, w.Code)
@@ -182,29 +558,83 @@ func TestUserHandler_ListUsers(t *testing.T) {
 	require.Len(t, users, 1)
 }
 
-func TestUserHandler_GetUserByID_Success(t *testing.T) {
+func TestUserHandler_ListUsers_Filtered(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserService := mocks.NewMockUserService(ctrl)
 	logger := zap.NewNop()
-	handler := NewUserHandler(mockUserService, logger)
+	handler := NewUserHandler(mockUserService, testCursors, logger)
 
-	userID := newUUID()
-	req, err := http.NewRequest("GET", "/users/"+userID, nil)
+	req, err := http.NewRequest("GET", "/users?q=+jane+&city=Chicago&state=IL&sort=username&order=ASC", nil)
 	require.NoError(t, err)
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
-	c.Params = gin.Params{{Key: "id", Value: userID}}
 
-	user := &domain.User{ID: userID}
-	mockUserService.EXPECT().Get(gomock.Any(), userID).Return(user, nil).Times(1)
+	params := domain.ListUsersParams{
+		Query:      "jane",
+		City:       "Chicago",
+		State:      "IL",
+		PageNumber: 1,
+		PageSize:   10,
+		Sort:       domain.UserSortUsername,
+		Order:      domain.SortAsc,
+	}
+	mockUserService.EXPECT().List(gomock.Any(), params).Return(domain.PaginatedUsers{Users: []domain.User{}}, nil).Times(1)
 
-	handler.GetUserByID(c)
+	handler.ListUsers(c)
 	require.Equal(t, http.StatusOK, w.Code)
+}
 
-	var resp APIResponse
+func TestUserHandler_ListUsers_InvalidSort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	for _, query := range []string{
+		"sort=phone",
+		"sort=name%3BDROP+TABLE+users",
+		"order=sideways",
+		"limit=5&q=jane",
+	} {
+		req, err := http.NewRequest("GET", "/users?"+query, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.ListUsers(c)
+		require.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestUserHandler_GetUserByID_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	userID := newUUID()
+	req, err := http.NewRequest("GET", "/users/"+userID, nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: userID}}
+
+	user := &domain.User{ID: userID}
+	mockUserService.EXPECT().Get(gomock.Any(), userID).Return(user, nil).Times(1)
+
+	handler.GetUserByID(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp APIResponse
 	err = json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
 	require.Equal(t, "success", resp.Status)
@@ -220,7 +650,7 @@ func TestUserHandler_CountUsers(t *testing.T) {
 
 	mockUserService := mocks.NewMockUserService(ctrl)
 	logger := zap.NewNop()
-	handler := NewUserHandler(mockUserService, logger)
+	handler := NewUserHandler(mockUserService, testCursors, logger)
 
 	req, err := http.NewRequest("GET", "/users/count", nil)
 	require.NoError(t, err)
@@ -245,4 +675,712 @@ func TestUserHandler_CountUsers(t *testing.T) {
 	err = json.Unmarshal(dataBytes, &countData)
 	require.NoError(t, err)
 	require.Equal(t, 42, countData.Count)
-}
\ No newline at end of file
+}
+func TestUserHandler_CreateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	reqBody := `{"name": "Jane Doe", "username": "janedoe", "email": "jane@example.com", "phone": "+1 555-0100",
+		"address": {"street": "1 Main St", "city": "Springfield", "state": "IL", "zipcode": "62701"}}`
+	req, err := http.NewRequest("POST", "/users", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	mockUserService.EXPECT().Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, user *domain.User) error {
+			require.NotEmpty(t, user.ID)
+			require.Equal(t, "janedoe", user.Username)
+			require.Equal(t, "jane@example.com", user.Email)
+			require.NotEmpty(t, user.Address.ID)
+			require.Equal(t, user.ID, user.Address.UserID)
+			require.Equal(t, "Springfield", user.Address.City)
+			return nil
+		}).Times(1)
+
+	handler.CreateUser(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp APIResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, "User created successfully", resp.Message)
+}
+
+func TestUserHandler_CreateUser_InvalidInput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	reqBody := `{"name": "Jane Doe", "username": "jane doe", "email": "not-an-email"}`
+	req, err := http.NewRequest("POST", "/users", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.CreateUser(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp domain.DomainError
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, domain.ErrInvalidInput.Code, resp.Code)
+	require.Contains(t, resp.FieldErrors, "username")
+	require.Contains(t, resp.FieldErrors, "email")
+}
+
+func TestUserHandler_CreateUser_Conflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	reqBody := `{"name": "Jane Doe", "username": "janedoe", "email": "jane@example.com"}`
+	req, err := http.NewRequest("POST", "/users", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	mockUserService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(domain.ErrUserAlreadyExists).Times(1)
+
+	handler.CreateUser(c)
+
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	var resp domain.DomainError
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, domain.ErrUserAlreadyExists.Code, resp.Code)
+}
+
+func TestUserHandler_PatchUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	userID := newUUID()
+	current := &domain.User{
+		ID:       userID,
+		Name:     "Jane Doe",
+		Username: "janedoe",
+		Email:    "jane@example.com",
+		Phone:    "+1 555-0100",
+		Address: domain.Address{
+			ID:      newUUID(),
+			UserID:  userID,
+			Street:  "1 Main St",
+			City:    "Springfield",
+			State:   "IL",
+			Zipcode: "62701",
+		},
+	}
+
+	reqBody := `{"name": "Jane Smith", "phone": null, "address": {"city": "Chicago"}}`
+	req, err := http.NewRequest("PATCH", "/users/"+userID, strings.NewReader(reqBody))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: userID}}
+	c.Set(middlewares.UserIDKey, userID)
+
+	mockUserService.EXPECT().Get(gomock.Any(), userID).Return(current, nil).Times(1)
+	mockUserService.EXPECT().Update(gomock.Any(), domain.Principal{UserID: userID, Role: domain.RoleUser}, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, actor domain.Principal, user *domain.User) error {
+			require.Equal(t, userID, user.ID)
+			require.Equal(t, "Jane Smith", user.Name)
+			require.Equal(t, "janedoe", user.Username)
+			require.Empty(t, user.Phone)
+			require.Equal(t, current.Address.ID, user.Address.ID)
+			require.Equal(t, "Chicago", user.Address.City)
+			require.Equal(t, "1 Main St", user.Address.Street)
+			return nil
+		}).Times(1)
+
+	handler.PatchUser(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp APIResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, "User updated successfully", resp.Message)
+}
+
+func TestUserHandler_PatchUser_InvalidField(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	userID := newUUID()
+	current := &domain.User{ID: userID, Name: "Jane Doe", Username: "janedoe", Email: "jane@example.com"}
+
+	req, err := http.NewRequest("PATCH", "/users/"+userID, strings.NewReader(`{"email": null}`))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: userID}}
+	c.Set(middlewares.UserIDKey, userID)
+
+	mockUserService.EXPECT().Get(gomock.Any(), userID).Return(current, nil).Times(1)
+
+	handler.PatchUser(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp domain.DomainError
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Contains(t, resp.FieldErrors, "email")
+}
+
+func TestUserHandler_DeleteUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	userID, targetID := newUUID(), newUUID()
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("/users/%s?mode=reassign&reassignTo=%s", userID, targetID), nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: userID}}
+	c.Set(middlewares.UserIDKey, "admin")
+	c.Set(middlewares.RoleKey, string(domain.RoleAdmin))
+
+	opts := domain.DeleteUserOptions{Mode: domain.UserDeleteReassign, ReassignTo: targetID}
+	mockUserService.EXPECT().Delete(gomock.Any(), domain.Principal{UserID: "admin", Role: domain.RoleAdmin}, userID, opts).Return(nil).Times(1)
+
+	handler.DeleteUser(c)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Empty(t, w.Body.Bytes())
+}
+
+func TestUserHandler_DeleteUser_MissingReassignTarget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	userID := newUUID()
+	req, err := http.NewRequest("DELETE", "/users/"+userID+"?mode=reassign", nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: userID}}
+	c.Set(middlewares.UserIDKey, userID)
+
+	handler.DeleteUser(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp domain.DomainError
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Contains(t, resp.FieldErrors, "reassignTo")
+}
+
+func TestUserHandler_ModifyOtherUser_Forbidden(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		call   func(h *UserHandler, c *gin.Context)
+	}{
+		{"put", "PUT", `{"name": "Jane Smith", "username": "janesmith", "email": "jane@example.com"}`, (*UserHandler).UpdateUser},
+		{"patch", "PATCH", `{"name": "Jane Smith"}`, (*UserHandler).PatchUser},
+		{"delete", "DELETE", "", (*UserHandler).DeleteUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := repomocks.NewMockusersRepo(ctrl)
+			logger := zap.NewNop()
+			handler := NewUserHandler(usersservice.New(mockRepo, logger), testCursors, logger)
+
+			userID := newUUID()
+			current := &domain.User{ID: userID, Name: "Jane Doe", Username: "janedoe", Email: "jane@example.com"}
+			mockRepo.EXPECT().Get(gomock.Any(), userID).Return(current, nil).AnyTimes()
+
+			req, err := http.NewRequest(tt.method, "/users/"+userID, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = gin.Params{{Key: "id", Value: userID}}
+			c.Set(middlewares.UserIDKey, newUUID())
+			c.Set(middlewares.RoleKey, string(domain.RoleUser))
+
+			tt.call(handler, c)
+
+			require.Equal(t, http.StatusForbidden, w.Code)
+
+			var resp domain.DomainError
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			require.Equal(t, domain.ErrUserForbidden.Code, resp.Code)
+		})
+	}
+}
+
+func TestUserHandler_DeleteUser_ReassignToOtherUser_Forbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repomocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(usersservice.New(mockRepo, logger), testCursors, logger)
+
+	userID, targetID := newUUID(), newUUID()
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("/users/%s?mode=reassign&reassignTo=%s", userID, targetID), nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: userID}}
+	c.Set(middlewares.UserIDKey, userID)
+	c.Set(middlewares.RoleKey, string(domain.RoleUser))
+
+	handler.DeleteUser(c)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	var resp domain.DomainError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, domain.ErrReassignForbidden.Code, resp.Code)
+}
+
+func TestUserHandler_ListUsers_Cursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	current := domain.Cursor{CreatedAt: "2025-01-02T00:00:00Z", ID: newUUID(), Direction: domain.CursorNext}
+	token, err := testCursors.Encode(current)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/users?limit=1&cursor="+token, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	next := &domain.Cursor{CreatedAt: "2025-01-01T00:00:00Z", ID: newUUID(), Direction: domain.CursorNext}
+	paginatedUsers := domain.PaginatedUsers{
+		Pagination: domain.Pagination{TotalPages: 3, TotalSize: 3},
+		Users:      []domain.User{{ID: next.ID}},
+		NextCursor: next,
+	}
+	mockUserService.EXPECT().ListByCursor(gomock.Any(), domain.CursorParams{Cursor: &current, Limit: 1}).Return(paginatedUsers, nil).Times(1)
+
+	handler.ListUsers(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp APIResponse
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Pagination)
+	require.Empty(t, resp.Pagination.PrevCursor)
+
+	var decoded domain.Cursor
+	require.NoError(t, testCursors.Decode(resp.Pagination.NextCursor, &decoded))
+	require.Equal(t, *next, decoded)
+}
+
+func TestUserHandler_ListUsers_InvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUserHandler(mockUserService, testCursors, logger)
+
+	forged, err := cursor.NewCodec([]byte("other-secret")).Encode(domain.Cursor{ID: newUUID(), Direction: domain.CursorNext})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/users?cursor="+forged, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.ListUsers(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAPIKeyHandler_CreateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyService := mocks.NewMockAPIKeyService(ctrl)
+	logger := zap.NewNop()
+	handler := NewAPIKeyHandler(mockAPIKeyService, logger)
+
+	req, err := http.NewRequest("POST", "/admin/api-keys", strings.NewReader(`{"ownerId": " owner-1 ", "name": "ci", "scopes": ["users:read", "posts:read", "users:read"]}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	scopes := domain.Scopes{domain.ScopePostsRead, domain.ScopeUsersRead}
+	params := domain.CreateAPIKeyParams{OwnerID: "owner-1", Name: "ci", Scopes: scopes}
+	issued := &domain.IssuedAPIKey{
+		APIKey: domain.APIKey{ID: newUUID(), OwnerID: "owner-1", Scopes: scopes, Prefix: "abc123", Salt: "salt", Hash: "hash"},
+		Key:    "postr_abc123_secret",
+	}
+	mockAPIKeyService.EXPECT().Create(gomock.Any(), params).Return(issued, nil).Times(1)
+
+	handler.CreateAPIKey(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotContains(t, w.Body.String(), `"hash"`)
+	require.NotContains(t, w.Body.String(), `"salt"`)
+
+	var resp APIResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data, ok := resp.Data.(map[string]interface{})
+	require.True(t, ok, "expected Data to be a map")
+	require.Equal(t, "postr_abc123_secret", data["key"])
+	require.Equal(t, []interface{}{"posts:read", "users:read"}, data["scopes"])
+}
+
+func TestAPIKeyHandler_CreateAPIKey_InvalidScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyService := mocks.NewMockAPIKeyService(ctrl)
+	logger := zap.NewNop()
+	handler := NewAPIKeyHandler(mockAPIKeyService, logger)
+
+	req, err := http.NewRequest("POST", "/admin/api-keys", strings.NewReader(`{"ownerId": "owner-1", "scopes": ["posts:read", "root"]}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.CreateAPIKey(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAPIKeyHandler_RevokeAPIKey_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyService := mocks.NewMockAPIKeyService(ctrl)
+	logger := zap.NewNop()
+	handler := NewAPIKeyHandler(mockAPIKeyService, logger)
+
+	id := newUUID()
+	req, err := http.NewRequest("DELETE", "/admin/api-keys/"+id, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: id}}
+
+	mockAPIKeyService.EXPECT().Revoke(gomock.Any(), id).Return(domain.ErrAPIKeyNotFound).Times(1)
+
+	handler.RevokeAPIKey(c)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPIKeyHandler_CreateAPIKey_ExpiresAt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyService := mocks.NewMockAPIKeyService(ctrl)
+	logger := zap.NewNop()
+	handler := NewAPIKeyHandler(mockAPIKeyService, logger)
+
+	future := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	mockAPIKeyService.EXPECT().Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, params domain.CreateAPIKeyParams) (*domain.IssuedAPIKey, error) {
+			require.NotNil(t, params.ExpiresAt)
+			require.True(t, future.Equal(*params.ExpiresAt))
+			return &domain.IssuedAPIKey{APIKey: domain.APIKey{ID: newUUID(), OwnerID: "owner-1"}}, nil
+		}).Times(1)
+
+	tests := []struct {
+		expiresAt string
+		status    int
+	}{
+		{future.Format(time.RFC3339), http.StatusOK},
+		{past, http.StatusBadRequest},
+		{"tomorrow", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		body := fmt.Sprintf(`{"ownerId": "owner-1", "scopes": ["posts:read"], "expiresAt": %q}`, tt.expiresAt)
+		req, err := http.NewRequest("POST", "/admin/api-keys", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.CreateAPIKey(c)
+
+		require.Equal(t, tt.status, w.Code, tt.expiresAt)
+	}
+}
+
+func TestAPIKeyHandler_RotateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyService := mocks.NewMockAPIKeyService(ctrl)
+	logger := zap.NewNop()
+	handler := NewAPIKeyHandler(mockAPIKeyService, logger)
+
+	id := newUUID()
+	issued := &domain.IssuedAPIKey{APIKey: domain.APIKey{ID: newUUID(), OwnerID: "owner-1"}, Key: "postr_abc123_secret"}
+	mockAPIKeyService.EXPECT().Rotate(gomock.Any(), id, time.Duration(0)).Return(issued, nil).Times(1)
+	mockAPIKeyService.EXPECT().Rotate(gomock.Any(), id, 24*time.Hour).Return(issued, nil).Times(1)
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{"", http.StatusOK},
+		{`{"gracePeriod": "24h"}`, http.StatusOK},
+		{`{"gracePeriod": "a day"}`, http.StatusBadRequest},
+		{`{"gracePeriod": "-1h"}`, http.StatusBadRequest},
+		{`{"gracePeriod": "1000h"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("POST", "/admin/api-keys/"+id+"/rotate", strings.NewReader(tt.body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: id}}
+
+		handler.RotateAPIKey(c)
+
+		require.Equal(t, tt.status, w.Code, tt.body)
+	}
+}
+
+func TestUsageHandler_GetMyUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQuotaService := mocks.NewMockQuotaService(ctrl)
+	logger := zap.NewNop()
+	handler := NewUsageHandler(mockQuotaService, logger)
+
+	usage := []domain.QuotaUsage{
+		{Kind: domain.QuotaRequests, Period: "2025-01", Used: 10, Limit: 100, Remaining: 90, ResetsAt: "2025-02-01T00:00:00Z"},
+		{Kind: domain.QuotaPosts, Period: "2025-01-31", Used: 0, Limit: 5, Remaining: 5, ResetsAt: "2025-02-01T00:00:00Z"},
+	}
+	mockQuotaService.EXPECT().Usage(gomock.Any(), "key-1").Return(usage, nil).Times(1)
+
+	req, err := http.NewRequest("GET", "/me/usage", nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middlewares.APIKeyIDKey, "key-1")
+
+	handler.GetMyUsage(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp APIResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	data, ok := resp.Data.([]interface{})
+	require.True(t, ok, "expected Data to be a list")
+	require.Len(t, data, 2)
+	require.Equal(t, float64(90), data[0].(map[string]interface{})["remaining"])
+
+	// bearer tokens have no quotas
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middlewares.UserIDKey, "user-1")
+
+	handler.GetMyUsage(c)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAuthHandler_Login(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthService := mocks.NewMockAuthService(ctrl)
+	logger := zap.NewNop()
+	handler := NewAuthHandler(mockAuthService, logger)
+
+	tokens := &domain.AuthTokens{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, RefreshToken: "refresh"}
+	mockAuthService.EXPECT().Login(gomock.Any(), "bret", " correct horse ").Return(tokens, nil).Times(1)
+	mockAuthService.EXPECT().Login(gomock.Any(), "bret", "wrong").Return(nil, domain.ErrInvalidCredentials).Times(1)
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"username": " bret ", "password": " correct horse "}`, http.StatusOK},
+		{`{"username": "bret", "password": "wrong"}`, http.StatusUnauthorized},
+		{`{"username": "bret"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("POST", "/auth/login", strings.NewReader(tt.body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.Login(c)
+
+		require.Equal(t, tt.code, w.Code, tt.body)
+	}
+}
+
+func TestAuthHandler_RefreshAndLogout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthService := mocks.NewMockAuthService(ctrl)
+	logger := zap.NewNop()
+	handler := NewAuthHandler(mockAuthService, logger)
+
+	tokens := &domain.AuthTokens{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, RefreshToken: "refresh-2"}
+	mockAuthService.EXPECT().Refresh(gomock.Any(), "refresh-1").Return(tokens, nil).Times(1)
+	mockAuthService.EXPECT().Refresh(gomock.Any(), "reused").Return(nil, domain.ErrInvalidRefreshToken).Times(1)
+	mockAuthService.EXPECT().Logout(gomock.Any(), "refresh-2").Return(nil).Times(1)
+
+	tests := []struct {
+		handle gin.HandlerFunc
+		body   string
+		code   int
+	}{
+		{handler.Refresh, `{"refreshToken": "refresh-1"}`, http.StatusOK},
+		{handler.Refresh, `{"refreshToken": "reused"}`, http.StatusUnauthorized},
+		{handler.Refresh, `{}`, http.StatusBadRequest},
+		{handler.Logout, `{"refreshToken": "refresh-2"}`, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("POST", "/auth", strings.NewReader(tt.body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		tt.handle(c)
+
+		require.Equal(t, tt.code, w.Code, tt.body)
+	}
+}
+
+func TestAuthHandler_SetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthService := mocks.NewMockAuthService(ctrl)
+	logger := zap.NewNop()
+	handler := NewAuthHandler(mockAuthService, logger)
+
+	userID := newUUID()
+	principal := domain.Principal{UserID: "someone-else", Role: domain.RoleUser}
+	mockAuthService.EXPECT().SetPassword(gomock.Any(), principal, userID, "correct horse").Return(domain.ErrPasswordForbidden).Times(1)
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"password": "correct horse"}`, http.StatusForbidden},
+		{`{"password": "short"}`, http.StatusBadRequest},
+		{fmt.Sprintf(`{"password": "%s"}`, strings.Repeat("é", 40)), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("PUT", "/users/"+userID+"/password", strings.NewReader(tt.body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: userID}}
+		c.Set(middlewares.UserIDKey, "someone-else")
+
+		handler.SetPassword(c)
+
+		require.Equal(t, tt.code, w.Code, tt.body)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+)
+
+// applyMergePatch applies a JSON merge patch (RFC 7386) to target and decodes the result
+// back into the target's type. Fields set to null in the patch are removed.
+func applyMergePatch[T any](target T, patch map[string]any) (T, error) {
+	var result T
+
+	raw, err := json.Marshal(target)
+	if err != nil {
+		return result, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return result, err
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func mergePatch(target any, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"strings"
+	"time"
+
 	"github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/victor-nach/postr-backend/internal/domain"
 )
@@ -21,28 +24,293 @@ type Count struct {
 	Count int `json:"count"`
 }
 
+// createPostRequest is the payload for creating a post, UserID defaults to the caller
 type createPostRequest struct {
 	UserID string `json:"userId"`
 	Title  string `json:"title"`
 	Body   string `json:"body"`
 }
 
+// createAPIKeyRequest is the payload for issuing an API key, OwnerID is the user the key acts as
+type createAPIKeyRequest struct {
+	OwnerID   string   `json:"ownerId"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expiresAt"`
+}
+
+// rotateAPIKeyRequest is the optional payload of a rotation, GracePeriod keeps the old key working for a while
+type rotateAPIKeyRequest struct {
+	GracePeriod string `json:"gracePeriod"`
+}
+
+// loginRequest is the payload for a username and password login
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// refreshTokenRequest is the payload of refresh and logout
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type setPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// feedRequest holds the query parameters of the global feed
+type feedRequest struct {
+	PageNumber int    `json:"pageNumber"`
+	PageSize   int    `json:"pageSize"`
+	Since      string `json:"since"`
+	Until      string `json:"until"`
+}
+
+// searchPostsRequest holds the query parameters of a post search
+type searchPostsRequest struct {
+	Query      string `json:"q"`
+	PageNumber int    `json:"pageNumber"`
+	PageSize   int    `json:"pageSize"`
+}
+
+type updatePostRequest struct {
+	Title *string `json:"title"`
+	Body  *string `json:"body"`
+}
+
+// userRequest is the payload for creating and updating users
+type userRequest struct {
+	Name     string          `json:"name"`
+	Username string          `json:"username"`
+	Email    string          `json:"email"`
+	Phone    string          `json:"phone"`
+	Address  *addressRequest `json:"address"`
+}
+
+type addressRequest struct {
+	Street  string `json:"street"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+	Zipcode string `json:"zipcode"`
+}
+
+// newUserRequest builds the request representation of an existing user, used as the
+// target document when applying a merge patch
+func newUserRequest(user *domain.User) userRequest {
+	req := userRequest{
+		Name:     user.Name,
+		Username: user.Username,
+		Email:    user.Email,
+		Phone:    user.Phone,
+	}
+	if user.Address.ID != "" {
+		req.Address = &addressRequest{
+			Street:  user.Address.Street,
+			City:    user.Address.City,
+			State:   user.Address.State,
+			Zipcode: user.Address.Zipcode,
+		}
+	}
+	return req
+}
+
+// toUser builds the updated user from the request, keeping the identifiers of current
+func (r userRequest) toUser(current *domain.User) *domain.User {
+	user := &domain.User{
+		ID:        current.ID,
+		Name:      r.Name,
+		Username:  r.Username,
+		Email:     r.Email,
+		Phone:     r.Phone,
+		CreatedAt: current.CreatedAt,
+	}
+	if r.Address != nil {
+		addressID := current.Address.ID
+		if addressID == "" {
+			addressID = newUUID()
+		}
+		user.Address = domain.Address{
+			ID:      addressID,
+			UserID:  current.ID,
+			Street:  r.Address.Street,
+			City:    r.Address.City,
+			State:   r.Address.State,
+			Zipcode: r.Address.Zipcode,
+		}
+	}
+	return user
+}
+
+func (r *userRequest) sanitize() {
+	r.Name = sanitizeInput(strings.TrimSpace(r.Name))
+	r.Username = sanitizeInput(strings.TrimSpace(r.Username))
+	r.Email = sanitizeInput(strings.TrimSpace(r.Email))
+	r.Phone = sanitizeInput(strings.TrimSpace(r.Phone))
+	if r.Address != nil {
+		r.Address.Street = sanitizeInput(strings.TrimSpace(r.Address.Street))
+		r.Address.City = sanitizeInput(strings.TrimSpace(r.Address.City))
+		r.Address.State = sanitizeInput(strings.TrimSpace(r.Address.State))
+		r.Address.Zipcode = sanitizeInput(strings.TrimSpace(r.Address.Zipcode))
+	}
+}
+
+type deleteUserRequest struct {
+	ID         string
+	Mode       string `json:"mode"`
+	ReassignTo string `json:"reassignTo"`
+}
+
+type listPostsRequest struct {
+	UserID     string `json:"userId"`
+	PageNumber int    `json:"pageNumber"`
+	PageSize   int    `json:"pageSize"`
+	Sort       string `json:"sort"`
+	Order      string `json:"order"`
+}
+
+// cursorRequest holds the query parameters of cursor based pagination
+type cursorRequest struct {
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
+
 type listUsersRequest struct {
-	PageNumber int `json:"pageNumber"`
-	PageSize   int `json:"pageSize"`
+	Query      string `json:"q"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PageNumber int    `json:"pageNumber"`
+	PageSize   int    `json:"pageSize"`
+	Sort       string `json:"sort"`
+	Order      string `json:"order"`
 }
 
 func (r listUsersRequest) Validate() error {
 	return validation.ValidateStruct(&r,
+		validation.Field(&r.Query, validation.RuneLength(0, 100)),
+		validation.Field(&r.City, validation.RuneLength(0, 100)),
+		validation.Field(&r.State, validation.RuneLength(0, 100)),
 		validation.Field(&r.PageNumber, validation.Required, validation.Min(1)),
 		validation.Field(&r.PageSize, validation.Required, validation.Min(1), validation.Max(100)),
+		validation.Field(&r.Sort, validation.Required, validation.In(domain.UserSortCreatedAt, domain.UserSortName, domain.UserSortUsername, domain.UserSortEmail)),
+		validation.Field(&r.Order, validation.Required, validation.In(string(domain.SortAsc), string(domain.SortDesc))),
 	)
 }
 
 func (r createPostRequest) Validate() error {
 	return validation.ValidateStruct(&r,
-		validation.Field(&r.UserID, validation.Required, validation.By(isCompactUUID)),
+		validation.Field(&r.UserID, validation.When(r.UserID != "", validation.By(isCompactUUID))),
 		validation.Field(&r.Title, validation.Required, validation.Length(1, 255)),
 		validation.Field(&r.Body, validation.Required, validation.Length(1, 2000)),
 	)
 }
+
+func (r createAPIKeyRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.OwnerID, validation.Required, validation.Match(principalIDRegex).Error("must be 1-64 letters, digits, '.', '_' or '-'")),
+		validation.Field(&r.Name, validation.RuneLength(0, 100)),
+		validation.Field(&r.Scopes, validation.Required, validation.Each(validation.By(isScope))),
+		validation.Field(&r.ExpiresAt, validation.Date(time.RFC3339).Error("must be an RFC3339 timestamp"), validation.By(isFuture)),
+	)
+}
+
+func (r rotateAPIKeyRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.GracePeriod, validation.By(isDuration(0, maxRotationGrace))),
+	)
+}
+
+func (r loginRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Username, validation.Required, validation.Length(3, 50)),
+		validation.Field(&r.Password, validation.Required, validation.By(isPasswordLength)),
+	)
+}
+
+func (r refreshTokenRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.RefreshToken, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+func (r setPasswordRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Password, validation.Required, validation.RuneLength(8, 0), validation.By(isPasswordLength)),
+	)
+}
+
+func (r cursorRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Limit, validation.Required, validation.Min(1), validation.Max(100)),
+	)
+}
+
+func (r listPostsRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.UserID, validation.Required, validation.By(isCompactUUID)),
+		validation.Field(&r.PageNumber, validation.Required, validation.Min(1)),
+		validation.Field(&r.PageSize, validation.Required, validation.Min(1), validation.Max(100)),
+		validation.Field(&r.Sort, validation.Required, validation.In(domain.PostSortCreatedAt, domain.PostSortUpdatedAt, domain.PostSortTitle)),
+		validation.Field(&r.Order, validation.Required, validation.In(string(domain.SortAsc), string(domain.SortDesc))),
+	)
+}
+
+func (r feedRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.PageNumber, validation.Required, validation.Min(1)),
+		validation.Field(&r.PageSize, validation.Required, validation.Min(1), validation.Max(100)),
+		validation.Field(&r.Since, validation.Date(time.RFC3339).Error("must be an RFC3339 timestamp")),
+		validation.Field(&r.Until,
+			validation.Date(time.RFC3339).Error("must be an RFC3339 timestamp"),
+			validation.When(r.Since != "", validation.By(isAfter(r.Since))),
+		),
+	)
+}
+
+func (r searchPostsRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Query, validation.Required, validation.RuneLength(1, 200)),
+		validation.Field(&r.PageNumber, validation.Required, validation.Min(1)),
+		validation.Field(&r.PageSize, validation.Required, validation.Min(1), validation.Max(100)),
+	)
+}
+
+func (r updatePostRequest) Validate() error {
+	if r.Title == nil && r.Body == nil {
+		return validation.NewError("validation_update_post_empty", "at least one of title or body is required")
+	}
+
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Title, validation.NilOrNotEmpty, validation.Length(1, 255)),
+		validation.Field(&r.Body, validation.NilOrNotEmpty, validation.Length(1, 2000)),
+	)
+}
+
+func (r deleteUserRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Mode, validation.In(string(domain.UserDeleteCascade), string(domain.UserDeleteReassign))),
+		validation.Field(&r.ReassignTo,
+			validation.When(r.Mode == string(domain.UserDeleteReassign), validation.Required, validation.By(isCompactUUID), validation.NotIn(r.ID).Error("must be a different user")).
+				Else(validation.Empty),
+		),
+	)
+}
+
+func (r userRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 255)),
+		validation.Field(&r.Username, validation.Required, validation.Length(3, 50), validation.Match(usernameRegex).Error("must contain only letters, numbers, dots or underscores")),
+		validation.Field(&r.Email, validation.Required, validation.Length(3, 255), validation.Match(emailRegex).Error("must be a valid email address")),
+		validation.Field(&r.Phone, validation.Length(0, 30), validation.Match(phoneRegex).Error("must be a valid phone number")),
+		validation.Field(&r.Address),
+	)
+}
+
+func (r addressRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Street, validation.Required, validation.Length(1, 255)),
+		validation.Field(&r.City, validation.Required, validation.Length(1, 100)),
+		validation.Field(&r.State, validation.Required, validation.Length(1, 100)),
+		validation.Field(&r.Zipcode, validation.Required, validation.Length(1, 20)),
+	)
+}
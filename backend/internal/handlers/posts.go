@@ -11,18 +11,22 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/internal/middlewares"
+	"github.com/victor-nach/postr-backend/pkg/cursor"
 )
 
 type PostHandler struct {
 	service domain.PostService
+	cursors *cursor.Codec
 	logger  *zap.Logger
 }
 
-func NewPostHandler(service domain.PostService, logger *zap.Logger) *PostHandler {
+func NewPostHandler(service domain.PostService, cursors *cursor.Codec, logger *zap.Logger) *PostHandler {
 	logger = logger.With(zap.String("package", "handlers"))
 
 	return &PostHandler{
 		service: service,
+		cursors: cursors,
 		logger:  logger,
 	}
 }
@@ -30,11 +34,20 @@ func NewPostHandler(service domain.PostService, logger *zap.Logger) *PostHandler
 func (h *PostHandler) CreatePost(c *gin.Context) {
 	logr := h.logger.With(zap.String("method", "CreatePost"))
 
+	principal, ok := principalFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, domain.ErrMissingAPIKey)
+		return
+	}
+
 	req, err := h.validateCreatePost(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
+	if req.UserID == "" {
+		req.UserID = principal.UserID
+	}
 
 	post := &domain.Post{
 		ID:        newUUID(),
@@ -44,11 +57,15 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		CreatedAt: time.Now().Format(time.RFC3339),
 	}
 
-	if err := h.service.Create(c.Request.Context(), post); err != nil {
+	if err := h.service.Create(c.Request.Context(), principal, post); err != nil {
 		if errors.Is(err, domain.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, err)
 			return
 		}
+		if errors.Is(err, domain.ErrPostForbidden) {
+			c.JSON(http.StatusForbidden, err)
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -64,49 +81,284 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+func (h *PostHandler) GetPostByID(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "GetPostByID"))
+
+	id, err := h.validateGetPostByID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	post, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("Post retrieved successfully", zap.String("id", id))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "Post retrieved successfully",
+		Data:    post,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *PostHandler) UpdatePost(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "UpdatePost"))
+
+	principal, ok := principalFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, domain.ErrMissingAPIKey)
+		return
+	}
+
+	id, req, err := h.validateUpdatePost(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	update := domain.PostUpdate{
+		Title:     req.Title,
+		Body:      req.Body,
+		UpdatedAt: time.Now().Format(time.RFC3339),
+	}
+
+	post, err := h.service.Update(c.Request.Context(), principal, id, update)
+	if err != nil {
+		if errors.Is(err, domain.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, err)
+			return
+		}
+		if errors.Is(err, domain.ErrPostForbidden) {
+			c.JSON(http.StatusForbidden, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("Post updated successfully", zap.Any("post", post))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "Post updated successfully",
+		Data:    post,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *PostHandler) ListPostRevisions(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "ListPostRevisions"))
+
+	id, err := h.validateGetPostByID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	revisions, err := h.service.ListRevisions(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrPostNotFound) {
+			c.JSON(http.StatusNotFound, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("Post revisions listed successfully", zap.String("id", id), zap.Int("count", len(revisions)))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "Post revisions listed successfully",
+		Data:    revisions,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func (h *PostHandler) ListPostsByUserID(c *gin.Context) {
 	logr := h.logger.With(zap.String("method", "ListPostsByUserID"))
 
-	userId, err := h.validateListPostsByUserID(c)
+	if isCursorQuery(c) {
+		h.listPostsByCursor(c, logr)
+		return
+	}
+
+	req, err := h.validateListPostsByUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	params := domain.ListPostsParams{
+		UserID:     req.UserID,
+		PageNumber: req.PageNumber,
+		PageSize:   req.PageSize,
+		Sort:       req.Sort,
+		Order:      domain.SortOrder(req.Order),
+	}
+
+	paginatedPosts, err := h.service.List(c.Request.Context(), params)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, domain.ErrInternalServer)
+		return
+	}
+
+	logr.Info("Posts listed successfully", zap.String("userId", req.UserID), zap.Int("count", len(paginatedPosts.Posts)))
+
+	resp := APIResponse{
+		Status:     successStatus,
+		Message:    "Posts listed successfully",
+		Pagination: &paginatedPosts.Pagination,
+		Data:       paginatedPosts.Posts,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// listPostsByCursor serves ListPostsByUserID in cursor mode (?cursor=...&limit=...)
+func (h *PostHandler) listPostsByCursor(c *gin.Context, logr *zap.Logger) {
+	userID, params, err := h.validateListPostsByCursor(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
-	posts, err := h.service.List(c.Request.Context(), userId)
+	paginatedPosts, err := h.service.ListByCursor(c.Request.Context(), userID, params)
 	if err != nil {
 		if errors.Is(err, domain.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, err)
 			return
 		}
+		c.JSON(http.StatusInternalServerError, domain.ErrInternalServer)
+		return
+	}
 
+	if err := encodeCursors(h.cursors, &paginatedPosts.Pagination, paginatedPosts.NextCursor, paginatedPosts.PrevCursor); err != nil {
+		logr.Error("Error encoding cursors", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, domain.ErrInternalServer)
 		return
 	}
 
-	logr.Info("Posts listed successfully", zap.String("userId", userId), zap.Int("count", len(posts)))
+	logr.Info("Posts listed successfully", zap.String("userId", userID), zap.Int("count", len(paginatedPosts.Posts)))
 
 	resp := APIResponse{
-		Status:  successStatus,
-		Message: "Posts listed successfully",
-		Data:    posts,
+		Status:     successStatus,
+		Message:    "Posts listed successfully",
+		Pagination: &paginatedPosts.Pagination,
+		Data:       paginatedPosts.Posts,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Feed lists the newest posts across all users with their authors
+func (h *PostHandler) Feed(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "Feed"))
+
+	req, err := h.validateFeed(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	params := domain.FeedParams{
+		Since:      req.Since,
+		Until:      req.Until,
+		PageNumber: req.PageNumber,
+		PageSize:   req.PageSize,
+	}
+
+	feed, err := h.service.Feed(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrInternalServer)
+		return
+	}
+
+	logr.Info("Feed listed successfully", zap.Int("count", len(feed.Posts)))
+
+	resp := APIResponse{
+		Status:     successStatus,
+		Message:    "Feed listed successfully",
+		Pagination: &feed.Pagination,
+		Data:       feed.Posts,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SearchPosts runs a full-text search over post titles and bodies
+func (h *PostHandler) SearchPosts(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "SearchPosts"))
+
+	req, err := h.validateSearchPosts(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	params := domain.SearchPostsParams{
+		Query:      req.Query,
+		PageNumber: req.PageNumber,
+		PageSize:   req.PageSize,
 	}
+
+	results, err := h.service.Search(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrInternalServer)
+		return
+	}
+
+	logr.Info("Posts searched successfully", zap.String("query", req.Query), zap.Int("count", len(results.Posts)))
+
+	resp := APIResponse{
+		Status:     successStatus,
+		Message:    "Posts searched successfully",
+		Pagination: &results.Pagination,
+		Data:       results.Posts,
+	}
+
 	c.JSON(http.StatusOK, resp)
 }
 
 func (h *PostHandler) DeletePost(c *gin.Context) {
 	logr := h.logger.With(zap.String("method", "DeletePost"))
 
+	principal, ok := principalFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, domain.ErrMissingAPIKey)
+		return
+	}
+
 	id, err := h.validateDeletePost(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
-	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+	if err := h.service.Delete(c.Request.Context(), principal, id); err != nil {
 		if errors.Is(err, domain.ErrPostNotFound) {
 			c.JSON(http.StatusNotFound, err)
 			return
 		}
+		if errors.Is(err, domain.ErrPostForbidden) {
+			c.JSON(http.StatusForbidden, err)
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -116,6 +368,21 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// principalFromContext returns the caller set on the context by the auth middleware
+func principalFromContext(c *gin.Context) (domain.Principal, bool) {
+	userID := c.GetString(middlewares.UserIDKey)
+	if userID == "" {
+		return domain.Principal{}, false
+	}
+
+	role := domain.Role(c.GetString(middlewares.RoleKey))
+	if role == "" {
+		role = domain.RoleUser
+	}
+
+	return domain.Principal{UserID: userID, Role: role}, true
+}
+
 func newUUID() string {
     id := uuid.New().String()
     return strings.ReplaceAll(id, "-", "")
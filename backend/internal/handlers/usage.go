@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/internal/middlewares"
+)
+
+type UsageHandler struct {
+	service domain.QuotaService
+	logger  *zap.Logger
+}
+
+func NewUsageHandler(service domain.QuotaService, logger *zap.Logger) *UsageHandler {
+	logger = logger.With(zap.String("package", "handlers"))
+
+	return &UsageHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetMyUsage returns how much of each quota the calling API key has used
+func (h *UsageHandler) GetMyUsage(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "GetMyUsage"))
+
+	keyID := c.GetString(middlewares.APIKeyIDKey)
+	if keyID == "" {
+		c.JSON(http.StatusBadRequest, domain.ErrInvalidInputWithStr("quotas are only tracked for API keys"))
+		return
+	}
+
+	usage, err := h.service.Usage(c.Request.Context(), keyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("Usage retrieved successfully", zap.String("api_key_id", keyID))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "Usage retrieved successfully",
+		Data:    usage,
+	}
+	c.JSON(http.StatusOK, resp)
+}
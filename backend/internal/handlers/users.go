@@ -3,23 +3,27 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/pkg/cursor"
 )
 
 type UserHandler struct {
 	service domain.UserService
+	cursors *cursor.Codec
 	logger  *zap.Logger
 }
 
-func NewUserHandler(service domain.UserService, logger *zap.Logger) *UserHandler {
+func NewUserHandler(service domain.UserService, cursors *cursor.Codec, logger *zap.Logger) *UserHandler {
 	logger = logger.With(zap.String("package", "handlers"))
 
 	return &UserHandler{
 		service: service,
+		cursors: cursors,
 		logger:  logger,
 	}
 }
@@ -27,13 +31,28 @@ func NewUserHandler(service domain.UserService, logger *zap.Logger) *UserHandler
 func (h *UserHandler) ListUsers(c *gin.Context) {
 	logr := h.logger.With(zap.String("method", "ListUsers"))
 
+	if isCursorQuery(c) {
+		h.listUsersByCursor(c, logr)
+		return
+	}
+
 	req, err := h.validateListUsers(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, err)
 		return
 	}
 
-	paginatedUsers, err := h.service.List(c.Request.Context(), req.PageNumber, req.PageSize)
+	params := domain.ListUsersParams{
+		Query:      req.Query,
+		City:       req.City,
+		State:      req.State,
+		PageNumber: req.PageNumber,
+		PageSize:   req.PageSize,
+		Sort:       req.Sort,
+		Order:      domain.SortOrder(req.Order),
+	}
+
+	paginatedUsers, err := h.service.List(c.Request.Context(), params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
@@ -50,6 +69,224 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "CreateUser"))
+
+	req, err := h.validateCreateUser(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	user := &domain.User{
+		ID:        newUUID(),
+		Name:      req.Name,
+		Username:  req.Username,
+		Email:     req.Email,
+		Phone:     req.Phone,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if req.Address != nil {
+		user.Address = domain.Address{
+			ID:      newUUID(),
+			UserID:  user.ID,
+			Street:  req.Address.Street,
+			City:    req.Address.City,
+			State:   req.Address.State,
+			Zipcode: req.Address.Zipcode,
+		}
+	}
+
+	if err := h.service.Create(c.Request.Context(), user); err != nil {
+		if errors.Is(err, domain.ErrUserAlreadyExists) {
+			c.JSON(http.StatusConflict, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	logr.Info("User created successfully", zap.Any("user", user))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "User created successfully",
+		Data:    user,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateUser replaces the user's fields and address with the request body
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "UpdateUser"))
+
+	principal, ok := principalFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, domain.ErrMissingAPIKey)
+		return
+	}
+
+	id, req, err := h.validateUpdateUser(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	current, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		h.writeUpdateError(c, err)
+		return
+	}
+
+	user := req.toUser(current)
+	if err := h.service.Update(c.Request.Context(), principal, user); err != nil {
+		h.writeUpdateError(c, err)
+		return
+	}
+
+	logr.Info("User updated successfully", zap.Any("user", user))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "User updated successfully",
+		Data:    user,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// PatchUser applies a JSON merge patch to the user. Fields set to null are cleared,
+// so "address": null removes the user's address.
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "PatchUser"))
+
+	principal, ok := principalFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, domain.ErrMissingAPIKey)
+		return
+	}
+
+	id, err := h.validateGetUserByID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	current, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		h.writeUpdateError(c, err)
+		return
+	}
+
+	req, err := h.validatePatchUser(c, current)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	user := req.toUser(current)
+	if err := h.service.Update(c.Request.Context(), principal, user); err != nil {
+		h.writeUpdateError(c, err)
+		return
+	}
+
+	logr.Info("User patched successfully", zap.Any("user", user))
+
+	resp := APIResponse{
+		Status:  successStatus,
+		Message: "User updated successfully",
+		Data:    user,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteUser removes the user with its address. Posts are deleted too, unless
+// mode=reassign is given, in which case they are moved to the reassignTo user.
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	logr := h.logger.With(zap.String("method", "DeleteUser"))
+
+	principal, ok := principalFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, domain.ErrMissingAPIKey)
+		return
+	}
+
+	req, err := h.validateDeleteUser(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	opts := domain.DeleteUserOptions{
+		Mode:       domain.UserDeleteMode(req.Mode),
+		ReassignTo: req.ReassignTo,
+	}
+	if err := h.service.Delete(c.Request.Context(), principal, req.ID, opts); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserForbidden), errors.Is(err, domain.ErrReassignForbidden):
+			c.JSON(http.StatusForbidden, err)
+		case errors.Is(err, domain.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, err)
+		default:
+			c.JSON(http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	logr.Info("User deleted successfully", zap.String("id", req.ID), zap.String("mode", req.Mode))
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *UserHandler) writeUpdateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUserForbidden):
+		c.JSON(http.StatusForbidden, err)
+	case errors.Is(err, domain.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrUserAlreadyExists):
+		c.JSON(http.StatusConflict, err)
+	default:
+		c.JSON(http.StatusInternalServerError, err)
+	}
+}
+
+// listUsersByCursor serves ListUsers in cursor mode (?cursor=...&limit=...)
+func (h *UserHandler) listUsersByCursor(c *gin.Context, logr *zap.Logger) {
+	params, err := h.validateListUsersByCursor(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	paginatedUsers, err := h.service.ListByCursor(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := encodeCursors(h.cursors, &paginatedUsers.Pagination, paginatedUsers.NextCursor, paginatedUsers.PrevCursor); err != nil {
+		logr.Error("Error encoding cursors", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, domain.ErrInternalServer)
+		return
+	}
+
+	logr.Info("Users listed successfully", zap.Int("count", len(paginatedUsers.Users)))
+
+	resp := APIResponse{
+		Status:     successStatus,
+		Message:    "Users listed successfully",
+		Pagination: &paginatedUsers.Pagination,
+		Data:       paginatedUsers.Users,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	logr := h.logger.With(zap.String("method", "GetUserByID"))
 
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"encoding/json"
 	"strconv"
 	"strings"
 	"regexp"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-ozzo/ozzo-validation/v4"
@@ -12,6 +16,7 @@ import (
 	"github.com/microcosm-cc/bluemonday"
 
 	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/pkg/cursor"
 )
 
 func sanitizeInput(input string) string {
@@ -19,7 +24,13 @@ func sanitizeInput(input string) string {
 	return p.Sanitize(input)
 }
 
-var compactUUIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+var (
+	compactUUIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	usernameRegex    = regexp.MustCompile(`^[a-zA-Z0-9_.]+$`)
+	emailRegex       = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+	phoneRegex       = regexp.MustCompile(`^\+?[0-9 ()x.\-]{7,30}$`)
+	principalIDRegex = regexp.MustCompile(`^[a-zA-Z0-9_.\-]{1,64}$`)
+)
 
 func isCompactUUID(value interface{}) error {
 	s, ok := value.(string)
@@ -32,6 +43,76 @@ func isCompactUUID(value interface{}) error {
 	return nil
 }
 
+// isAfter checks that an RFC3339 value is later than since, unparsable values are left to validation.Date
+func isAfter(since string) validation.RuleFunc {
+	return func(value interface{}) error {
+		s, _ := value.(string)
+		start, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil
+		}
+		end, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil
+		}
+		if !end.After(start) {
+			return validation.NewError("validation_time_after", "must be after since")
+		}
+		return nil
+	}
+}
+
+// isFuture checks that an RFC3339 value is in the future, unparsable values are left to validation.Date
+func isFuture(value interface{}) error {
+	s, _ := value.(string)
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	if !t.After(time.Now()) {
+		return validation.NewError("validation_time_future", "must be in the future")
+	}
+	return nil
+}
+
+// maxRotationGrace is the longest a rotated API key may keep working
+const maxRotationGrace = 30 * 24 * time.Hour
+
+// isDuration checks that a value such as 24h is a duration within [min, max]
+func isDuration(min, max time.Duration) validation.RuleFunc {
+	return func(value interface{}) error {
+		s, _ := value.(string)
+		if s == "" {
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return validation.NewError("validation_duration", "must be a duration such as 24h")
+		}
+		if d < min || d > max {
+			return validation.NewError("validation_duration_range", fmt.Sprintf("must be between %s and %s", min, max))
+		}
+		return nil
+	}
+}
+
+func isScope(value interface{}) error {
+	s, _ := value.(string)
+	if !slices.Contains(domain.AllScopes, domain.Scope(s)) {
+		return validation.NewError("validation_scope", "unknown scope")
+	}
+	return nil
+}
+
+// isPasswordLength rejects passwords longer than the 72 bytes bcrypt hashes
+func isPasswordLength(value interface{}) error {
+	s, _ := value.(string)
+	if len(s) > 72 {
+		return validation.NewError("validation_password_length", "must be at most 72 bytes")
+	}
+	return nil
+}
+
 func (h *PostHandler) validateCreatePost(c *gin.Context) (*createPostRequest, error) {
 	logr := h.logger.With(zap.String("method", "validateCreatePost"))
 	var req createPostRequest
@@ -58,23 +139,160 @@ func (h *PostHandler) validateCreatePost(c *gin.Context) (*createPostRequest, er
 	return &req, nil
 }
 
-func (h *PostHandler) validateListPostsByUserID(c *gin.Context) (string, error) {
+func (h *PostHandler) validateListPostsByUserID(c *gin.Context) (*listPostsRequest, error) {
 	logr := h.logger.With(zap.String("method", "validateListPostsByUserID"))
 
 	userId := c.Query("userId")
 	if userId == "" {
 		logr.Error("missing userId query parameter")
-		return "", domain.ErrInvalidInputWithStr("missing userId query parameter")
-		
+		return nil, domain.ErrInvalidInputWithStr("missing userId query parameter")
 	}
 
-	err := validation.Validate(userId, validation.By(isCompactUUID))
-    if err != nil {
-        logr.Error("invalid userId format", zap.Error(err))
-        return "", domain.ErrInvalidInputWithStr("invalid userId format")
-    }
+	pageNumber, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		return nil, err
+	}
+
+	req := listPostsRequest{
+		UserID:     userId,
+		PageNumber: pageNumber,
+		PageSize:   pageSize,
+		Sort:       c.DefaultQuery("sort", domain.PostSortCreatedAt),
+		Order:      strings.ToLower(c.DefaultQuery("order", string(domain.SortDesc))),
+	}
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	return &req, nil
+}
+
+func (h *PostHandler) validateFeed(c *gin.Context) (*feedRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateFeed"))
+
+	pageNumber, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		return nil, err
+	}
+
+	req := feedRequest{
+		PageNumber: pageNumber,
+		PageSize:   pageSize,
+		Since:      strings.TrimSpace(c.Query("since")),
+		Until:      strings.TrimSpace(c.Query("until")),
+	}
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
 
-	return userId, nil
+	return &req, nil
+}
+
+func (h *PostHandler) validateSearchPosts(c *gin.Context) (*searchPostsRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateSearchPosts"))
+
+	pageNumber, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		return nil, err
+	}
+
+	req := searchPostsRequest{
+		Query:      strings.TrimSpace(c.Query("q")),
+		PageNumber: pageNumber,
+		PageSize:   pageSize,
+	}
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	return &req, nil
+}
+
+func (h *PostHandler) validateListPostsByCursor(c *gin.Context) (string, domain.CursorParams, error) {
+	logr := h.logger.With(zap.String("method", "validateListPostsByCursor"))
+
+	userId := c.Query("userId")
+	if err := validation.Validate(userId, validation.Required, validation.By(isCompactUUID)); err != nil {
+		logr.Error("invalid userId", zap.Error(err))
+		return "", domain.CursorParams{}, domain.ErrInvalidInputWithStr("missing or invalid userId query parameter")
+	}
+
+	if c.Query("sort") != "" || c.Query("order") != "" {
+		return "", domain.CursorParams{}, domain.ErrInvalidInputWithStr("sort and order cannot be combined with cursor pagination")
+	}
+
+	params, err := parseCursorQuery(c, h.cursors)
+	if err != nil {
+		logr.Error("invalid cursor query", zap.Error(err))
+		return "", domain.CursorParams{}, err
+	}
+
+	return userId, params, nil
+}
+
+func (h *PostHandler) validateGetPostByID(c *gin.Context) (string, error) {
+	logr := h.logger.With(zap.String("method", "validateGetPostByID"))
+	id := c.Param("id")
+
+	if err := validation.Validate(id, validation.By(isCompactUUID)); err != nil {
+		logr.Error("invalid post id format", zap.Error(err))
+		return "", domain.ErrInvalidInputWithStr("invalid post id format")
+	}
+
+	return id, nil
+}
+
+func (h *PostHandler) validateUpdatePost(c *gin.Context) (string, *updatePostRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateUpdatePost"))
+
+	id, err := h.validateGetPostByID(c)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var req updatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logr.Error("Error binding JSON", zap.Error(err))
+		return "", nil, domain.ErrInvalidInput
+	}
+
+	if req.Title != nil {
+		title := sanitizeInput(strings.TrimSpace(*req.Title))
+		req.Title = &title
+	}
+	if req.Body != nil {
+		body := sanitizeInput(strings.TrimSpace(*req.Body))
+		req.Body = &body
+	}
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return "", nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return "", nil, domain.ErrInvalidInputWithStr(err.Error())
+	}
+
+	return id, &req, nil
 }
 
 func (h *PostHandler) validateDeletePost(c *gin.Context) (string, error) {
@@ -91,41 +309,386 @@ func (h *PostHandler) validateDeletePost(c *gin.Context) (string, error) {
 	return id, nil
 }
 
-func (h *UserHandler) validateListUsers(c *gin.Context) (*listUsersRequest, error) {
+func (h *UserHandler) validateCreateUser(c *gin.Context) (*userRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateCreateUser"))
+
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logr.Error("Error binding JSON", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	return h.validateUserRequest(logr, req)
+}
+
+func (h *UserHandler) validateUpdateUser(c *gin.Context) (string, *userRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateUpdateUser"))
+
+	id, err := h.validateGetUserByID(c)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logr.Error("Error binding JSON", zap.Error(err))
+		return "", nil, domain.ErrInvalidInput
+	}
+
+	validated, err := h.validateUserRequest(logr, req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return id, validated, nil
+}
+
+// validatePatchUser applies the JSON merge patch (RFC 7386) in the request body to the
+// current user and validates the resulting document
+func (h *UserHandler) validatePatchUser(c *gin.Context, current *domain.User) (*userRequest, error) {
+	logr := h.logger.With(zap.String("method", "validatePatchUser"))
+
+	body, err := c.GetRawData()
+	if err != nil {
+		logr.Error("Error reading request body", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	var patch map[string]any
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		logr.Error("Error decoding merge patch", zap.Error(err))
+		return nil, domain.ErrInvalidInputWithStr("request body must be a JSON object")
+	}
+
+	req, err := applyMergePatch(newUserRequest(current), patch)
+	if err != nil {
+		logr.Error("Error applying merge patch", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	return h.validateUserRequest(logr, req)
+}
+
+func (h *UserHandler) validateUserRequest(logr *zap.Logger, req userRequest) (*userRequest, error) {
+	req.sanitize()
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	return &req, nil
+}
+
+func (h *UserHandler) validateDeleteUser(c *gin.Context) (*deleteUserRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateDeleteUser"))
+
+	id, err := h.validateGetUserByID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	req := deleteUserRequest{
+		ID:         id,
+		Mode:       c.DefaultQuery("mode", string(domain.UserDeleteCascade)),
+		ReassignTo: c.Query("reassignTo"),
+	}
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	return &req, nil
+}
+
+// isCursorQuery reports whether the request asks for cursor based pagination
+func isCursorQuery(c *gin.Context) bool {
+	_, hasCursor := c.GetQuery("cursor")
+	_, hasLimit := c.GetQuery("limit")
+	return hasCursor || hasLimit
+}
+
+// parseCursorQuery reads the cursor and limit query parameters, limit defaults to 10.
+// Page based parameters cannot be combined with a cursor.
+func parseCursorQuery(c *gin.Context, codec *cursor.Codec) (domain.CursorParams, error) {
+	if c.Query("pageNumber") != "" || c.Query("pageSize") != "" {
+		return domain.CursorParams{}, domain.ErrInvalidInputWithStr("pageNumber and pageSize cannot be combined with cursor pagination")
+	}
+
+	req := cursorRequest{
+		Cursor: c.Query("cursor"),
+		Limit:  10,
+	}
+	if l := c.Query("limit"); l != "" {
+		num, err := strconv.Atoi(l)
+		if err != nil {
+			return domain.CursorParams{}, domain.ErrInvalidInputWithStr("invalid limit value")
+		}
+		req.Limit = num
+	}
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			return domain.CursorParams{}, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		return domain.CursorParams{}, domain.ErrInvalidInput
+	}
+
+	params := domain.CursorParams{Limit: req.Limit}
+	if req.Cursor == "" {
+		return params, nil
+	}
+
+	var decoded domain.Cursor
+	if err := codec.Decode(req.Cursor, &decoded); err != nil {
+		return domain.CursorParams{}, domain.ErrInvalidInputWithStr("invalid cursor")
+	}
+	if decoded.Direction != domain.CursorNext && decoded.Direction != domain.CursorPrev {
+		return domain.CursorParams{}, domain.ErrInvalidInputWithStr("invalid cursor")
+	}
+	params.Cursor = &decoded
+
+	return params, nil
+}
+
+// encodeCursors signs the neighbouring page cursors into the pagination block
+func encodeCursors(codec *cursor.Codec, pagination *domain.Pagination, next, prev *domain.Cursor) error {
+	if next != nil {
+		token, err := codec.Encode(next)
+		if err != nil {
+			return err
+		}
+		pagination.NextCursor = token
+	}
+
+	if prev != nil {
+		token, err := codec.Encode(prev)
+		if err != nil {
+			return err
+		}
+		pagination.PrevCursor = token
+	}
+
+	return nil
+}
+
+func (h *APIKeyHandler) validateCreateAPIKey(c *gin.Context) (*createAPIKeyRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateCreateAPIKey"))
+
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logr.Error("Error binding JSON", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	req.OwnerID = strings.TrimSpace(req.OwnerID)
+	req.Name = sanitizeInput(strings.TrimSpace(req.Name))
+	req.Scopes = slices.Compact(slices.Sorted(slices.Values(req.Scopes)))
+	req.ExpiresAt = strings.TrimSpace(req.ExpiresAt)
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	return &req, nil
+}
+
+func (h *APIKeyHandler) validateRotateAPIKey(c *gin.Context) (string, time.Duration, error) {
+	logr := h.logger.With(zap.String("method", "validateRotateAPIKey"))
+
+	id, err := h.validateAPIKeyID(c)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// the body is optional, rotating without one revokes the old key straight away
+	var req rotateAPIKeyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logr.Error("Error binding JSON", zap.Error(err))
+			return "", 0, domain.ErrInvalidInput
+		}
+	}
+
+	req.GracePeriod = strings.TrimSpace(req.GracePeriod)
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return "", 0, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return "", 0, domain.ErrInvalidInput
+	}
+
+	var grace time.Duration
+	if req.GracePeriod != "" {
+		grace, _ = time.ParseDuration(req.GracePeriod)
+	}
+
+	return id, grace, nil
+}
+
+func (h *APIKeyHandler) validateAPIKeyID(c *gin.Context) (string, error) {
+	logr := h.logger.With(zap.String("method", "validateAPIKeyID"))
+
+	id := c.Param("id")
+	if err := validation.Validate(id, validation.By(isCompactUUID)); err != nil {
+		logr.Error("invalid API key id format", zap.Error(err))
+		return "", domain.ErrInvalidInputWithStr("invalid API key id format")
+	}
+
+	return id, nil
+}
+
+func (h *AuthHandler) validateLogin(c *gin.Context) (*loginRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateLogin"))
+
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logr.Error("Error binding JSON", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	// passwords are compared as sent, only the username is trimmed
+	req.Username = strings.TrimSpace(req.Username)
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	return &req, nil
+}
+
+func (h *AuthHandler) validateRefreshToken(c *gin.Context) (*refreshTokenRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateRefreshToken"))
+
+	var req refreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logr.Error("Error binding JSON", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return nil, domain.ErrInvalidInput
+	}
+
+	return &req, nil
+}
+
+func (h *AuthHandler) validateSetPassword(c *gin.Context) (string, *setPasswordRequest, error) {
+	logr := h.logger.With(zap.String("method", "validateSetPassword"))
+
+	id := c.Param("id")
+	if err := validation.Validate(id, validation.By(isCompactUUID)); err != nil {
+		logr.Error("invalid userId format", zap.Error(err))
+		return "", nil, domain.ErrInvalidInputWithStr("invalid userId format")
+	}
+
+	var req setPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logr.Error("Error binding JSON", zap.Error(err))
+		return "", nil, domain.ErrInvalidInput
+	}
+
+	if err := req.Validate(); err != nil {
+		if verrs, ok := err.(validation.Errors); ok {
+			logr.Error("Validation errors", zap.Any("errors", verrs))
+			return "", nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
+		}
+		logr.Error("Validation error", zap.Error(err))
+		return "", nil, domain.ErrInvalidInput
+	}
+
+	return id, &req, nil
+}
+
+// parsePageQuery reads the pageNumber and pageSize query parameters, defaulting to the first page of 10
+func parsePageQuery(c *gin.Context) (int, int, error) {
 	pageNumber := 1
 	pageSize := 10
 
 	if pn := c.Query("pageNumber"); pn != "" {
-		if num, err := strconv.Atoi(pn); err == nil {
-			pageNumber = num
-		} else {
-			return nil, domain.ErrInvalidInputWithStr("nvalid pageNumber value")
+		num, err := strconv.Atoi(pn)
+		if err != nil {
+			return 0, 0, domain.ErrInvalidInputWithStr("invalid pageNumber value")
 		}
+		pageNumber = num
 	}
 
 	if ps := c.Query("pageSize"); ps != "" {
-		if num, err := strconv.Atoi(ps); err == nil {
-			pageSize = num
-		} else {
-			return nil, domain.ErrInvalidInputWithStr("nvalid pageSize value")
+		num, err := strconv.Atoi(ps)
+		if err != nil {
+			return 0, 0, domain.ErrInvalidInputWithStr("invalid pageSize value")
 		}
+		pageSize = num
+	}
+
+	return pageNumber, pageSize, nil
+}
+
+func (h *UserHandler) validateListUsers(c *gin.Context) (*listUsersRequest, error) {
+	pageNumber, pageSize, err := parsePageQuery(c)
+	if err != nil {
+		return nil, err
 	}
 
 	req := listUsersRequest{
+		Query:      strings.TrimSpace(c.Query("q")),
+		City:       strings.TrimSpace(c.Query("city")),
+		State:      strings.TrimSpace(c.Query("state")),
 		PageNumber: pageNumber,
 		PageSize:   pageSize,
+		Sort:       c.DefaultQuery("sort", domain.UserSortCreatedAt),
+		Order:      strings.ToLower(c.DefaultQuery("order", string(domain.SortDesc))),
 	}
 
 	if err := req.Validate(); err != nil {
 		if verrs, ok := err.(validation.Errors); ok {
 			return nil, domain.ErrInvalidInput.WithFieldErrors(verrs)
 		}
-		return nil, domain.ErrInvalidInputWithStr("Pagination validation failednvalid pageSize value")
+		return nil, domain.ErrInvalidInputWithStr("pagination validation failed")
 	}
 
 	return &req, nil
 }
 
+// validateListUsersByCursor parses a cursor mode user listing, which always runs newest first
+// over every user
+func (h *UserHandler) validateListUsersByCursor(c *gin.Context) (domain.CursorParams, error) {
+	for _, key := range []string{"q", "city", "state", "sort", "order"} {
+		if c.Query(key) != "" {
+			return domain.CursorParams{}, domain.ErrInvalidInputWithStr("filters and sorting cannot be combined with cursor pagination")
+		}
+	}
+
+	return parseCursorQuery(c, h.cursors)
+}
+
 func (h *UserHandler) validateGetUserByID(c *gin.Context) (string, error) {
 	logr := h.logger.With(zap.String("method", "GetUserByID"))
 
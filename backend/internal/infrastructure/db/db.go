@@ -1,46 +1,161 @@
 package db
 
 import (
+    "context"
     "database/sql"
     "fmt"
+    "net/url"
+    "os"
     "path/filepath"
-    "time"
+    "strings"
 
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgtype"
+    "github.com/jackc/pgx/v5/stdlib"
+    "gorm.io/driver/postgres"
     "gorm.io/driver/sqlite"
     "gorm.io/gorm"
 
+    "github.com/victor-nach/postr-backend/internal/config"
+    "github.com/victor-nach/postr-backend/migrations"
     "github.com/victor-nach/postr-backend/pkg/migrator"
     _ "modernc.org/sqlite"
 )
 
-// New initialzes the sqlite db and applies the latest migrations
-func New() (*gorm.DB, *sql.DB, error) {
-    dbFile := filepath.Join(".", "data", "app.db")
-
-    dsn := fmt.Sprintf("file:%s?cache=shared&_busy_timeout=5000&_journal_mode=WAL", dbFile)
-
-    sqlDB, err := sql.Open("sqlite", dsn)
+// New initialzes the sqlite or postgres db described by cfg and applies the latest migrations
+func New(cfg *config.Config) (*gorm.DB, *sql.DB, error) {
+    sqlDB, dialector, err := open(cfg)
     if err != nil {
-        return nil, nil, fmt.Errorf("failed to open sql db: %w", err)
+        return nil, nil, err
     }
 
     if err := sqlDB.Ping(); err != nil {
         return nil, nil, fmt.Errorf("failed to ping db: %w", err)
     }
 
-    sqlDB.SetMaxOpenConns(2)
-    sqlDB.SetMaxIdleConns(2)
-    sqlDB.SetConnMaxLifetime(time.Hour)
-    sqlDB.SetConnMaxIdleTime(10 * time.Minute)
+    sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+    sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+    sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+    sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
 
-    gormDB, err := gorm.Open(sqlite.Dialector{Conn: sqlDB}, &gorm.Config{})
+    gormDB, err := gorm.Open(dialector, &gorm.Config{})
     if err != nil {
         return nil, nil, fmt.Errorf("failed to open gorm db: %w", err)
     }
 
-    if err := migrator.Migrate(sqlDB, "file://migrations"); err != nil {
+    if err := Migrate(sqlDB, cfg.DBDriver, cfg.DBMigrations); err != nil {
         return nil, nil, fmt.Errorf("failed to apply latest migrations: %w", err)
     }
 
     return gormDB, sqlDB, nil
 }
+
+// open opens the database of the configured driver along with its gorm dialector
+func open(cfg *config.Config) (*sql.DB, gorm.Dialector, error) {
+    switch cfg.DBDriver {
+    case config.DBDriverPostgres:
+        sqlDB, err := OpenPostgres(cfg.DBDSN)
+        if err != nil {
+            return nil, nil, fmt.Errorf("failed to open sql db: %w", err)
+        }
+        return sqlDB, postgres.New(postgres.Config{Conn: sqlDB}), nil
+    case config.DBDriverSQLite:
+        dsn, err := sqliteDSN(cfg)
+        if err != nil {
+            return nil, nil, err
+        }
+        sqlDB, err := sql.Open("sqlite", dsn)
+        if err != nil {
+            return nil, nil, fmt.Errorf("failed to open sql db: %w", err)
+        }
+        return sqlDB, sqlite.Dialector{Conn: sqlDB}, nil
+    default:
+        return nil, nil, fmt.Errorf("unsupported database driver %q", cfg.DBDriver)
+    }
+}
+
+// OpenPostgres opens the postgres database at dsn with times returned in UTC, as sqlite returns
+// them. pgx decodes timestamptz columns in the local time zone whatever the session's, so the
+// sessions run in UTC for the server's own time formatting and the values are converted as they
+// are read.
+func OpenPostgres(dsn string) (*sql.DB, error) {
+    connConfig, err := pgx.ParseConfig(dsn)
+    if err != nil {
+        return nil, err
+    }
+    connConfig.RuntimeParams["timezone"] = "UTC"
+
+    return stdlib.OpenDB(*connConfig, stdlib.OptionAfterConnect(func(_ context.Context, conn *pgx.Conn) error {
+        conn.TypeMap().RegisterType(&pgtype.Type{Name: "timestamptz", OID: pgtype.TimestamptzOID, Codec: utcTimestamptzCodec{}})
+        return nil
+    })), nil
+}
+
+// utcTimestamptzCodec is pgx's timestamptz codec with the values it reads converted to UTC
+type utcTimestamptzCodec struct {
+    pgtype.TimestamptzCodec
+}
+
+func (c utcTimestamptzCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+    plan := c.TimestamptzCodec.PlanScan(m, oid, format, target)
+    if plan == nil {
+        return nil
+    }
+    return utcScanPlan{plan}
+}
+
+type utcScanPlan struct {
+    pgtype.ScanPlan
+}
+
+func (p utcScanPlan) Scan(src []byte, target any) error {
+    if err := p.ScanPlan.Scan(src, target); err != nil {
+        return err
+    }
+    if tstz, ok := target.(*pgtype.Timestamptz); ok {
+        tstz.Time = tstz.Time.UTC()
+    }
+    return nil
+}
+
+// Migrate applies the migrations of driver from the source URL, or the ones built into the binary when source is empty
+func Migrate(sqlDB *sql.DB, driver, source string) error {
+    if source == "" {
+        fsys, err := migrations.For(driver)
+        if err != nil {
+            return err
+        }
+        return migrator.MigrateFS(sqlDB, driver, fsys)
+    }
+    return migrator.Migrate(sqlDB, driver, source)
+}
+
+// sqliteDSN adds the busy timeout, journal mode and foreign keys pragmas to the configured DSN
+// and makes transactions immediate, creating the directory of the database file if needed
+func sqliteDSN(cfg *config.Config) (string, error) {
+    name, query, _ := strings.Cut(cfg.DBDSN, "?")
+    params, err := url.ParseQuery(query)
+    if err != nil {
+        return "", fmt.Errorf("invalid %s: %w", config.EnvDBDSN, err)
+    }
+
+    // the busy timeout goes first so the other pragmas wait for locks too
+    params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", cfg.DBBusyTimeout.Milliseconds()))
+    params.Add("_pragma", fmt.Sprintf("journal_mode(%s)", cfg.DBJournalMode))
+    // foreign keys are off by default in sqlite, they are needed for the ON DELETE rules
+    params.Add("_pragma", "foreign_keys(1)")
+    // a transaction takes the write lock when it begins, so one that reads before it writes waits
+    // for the busy timeout behind other writers instead of failing on a stale read
+    if params.Get("_txlock") == "" {
+        params.Set("_txlock", "immediate")
+    }
+
+    path := strings.TrimPrefix(name, "file:")
+    if path != "" && !strings.HasPrefix(path, ":") && params.Get("mode") != "memory" {
+        if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+            return "", fmt.Errorf("failed to create the database directory: %w", err)
+        }
+    }
+
+    return name + "?" + params.Encode(), nil
+}
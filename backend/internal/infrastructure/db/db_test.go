@@ -0,0 +1,101 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/victor-nach/postr-backend/internal/config"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		DBDriver: config.DBDriverSQLite,
+		// the directory does not exist yet
+		DBDSN:             "file:" + filepath.Join(dir, "nested", "app.db") + "?cache=shared",
+		DBMaxOpenConns:    2,
+		DBMaxIdleConns:    2,
+		DBConnMaxLifetime: time.Hour,
+		DBConnMaxIdleTime: time.Minute,
+		DBBusyTimeout:     1500 * time.Millisecond,
+		DBJournalMode:     "WAL",
+	}
+
+	_, sqlDB, err := New(cfg)
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	pragma := func(name string) string {
+		var v string
+		require.NoError(t, sqlDB.QueryRow("PRAGMA "+name).Scan(&v))
+		return v
+	}
+	require.Equal(t, "1500", pragma("busy_timeout"))
+	require.Equal(t, "wal", pragma("journal_mode"))
+	require.Equal(t, "1", pragma("foreign_keys"))
+
+	// the embedded migrations were applied
+	var version int
+	require.NoError(t, sqlDB.QueryRow("SELECT version FROM schema_migrations").Scan(&version))
+	require.GreaterOrEqual(t, version, 11)
+}
+
+func TestNew_InvalidDSN(t *testing.T) {
+	_, _, err := New(&config.Config{DBDriver: config.DBDriverSQLite, DBDSN: "file:app.db?cache=%zz"})
+	require.ErrorContains(t, err, config.EnvDBDSN)
+
+	_, _, err = New(&config.Config{DBDriver: "mysql", DBDSN: "app"})
+	require.ErrorContains(t, err, `unsupported database driver "mysql"`)
+}
+
+// TestNew_Postgres runs against the database in TEST_POSTGRES_DSN, see make test-postgres
+func TestNew_Postgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	_, sqlDB, err := New(&config.Config{
+		DBDriver:          config.DBDriverPostgres,
+		DBDSN:             dsn,
+		DBMaxOpenConns:    2,
+		DBMaxIdleConns:    2,
+		DBConnMaxLifetime: time.Hour,
+		DBConnMaxIdleTime: time.Minute,
+	})
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	var version int
+	require.NoError(t, sqlDB.QueryRow("SELECT version FROM schema_migrations").Scan(&version))
+	require.GreaterOrEqual(t, version, 11)
+
+	var now time.Time
+	require.NoError(t, sqlDB.QueryRow("SELECT now()").Scan(&now))
+	require.Equal(t, time.UTC, now.Location())
+}
+
+func TestUTCTimestamptzCodec(t *testing.T) {
+	// pgx would decode in the local zone, make it one that is not UTC
+	local := time.Local
+	time.Local = time.FixedZone("UTC+1", 60*60)
+	defer func() { time.Local = local }()
+
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Name: "timestamptz", OID: pgtype.TimestamptzOID, Codec: utcTimestamptzCodec{}})
+
+	want := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, format := range []int16{pgtype.BinaryFormatCode, pgtype.TextFormatCode} {
+		src, err := m.Encode(pgtype.TimestamptzOID, format, want, nil)
+		require.NoError(t, err)
+
+		var got pgtype.Timestamptz
+		require.NoError(t, m.PlanScan(pgtype.TimestamptzOID, format, &got).Scan(src, &got))
+		require.Equal(t, want, got.Time)
+	}
+}
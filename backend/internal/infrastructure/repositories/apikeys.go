@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *apiKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) Get(ctx context.Context, id string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List returns every key, including revoked ones, newest first
+func (r *apiKeyRepository) List(ctx context.Context) ([]domain.APIKey, error) {
+	keys := []domain.APIKey{}
+	if err := r.db.WithContext(ctx).
+		Order("created_at DESC").
+		Order("id DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke marks an active key as revoked, it returns gorm.ErrRecordNotFound if there is no active key with the id
+func (r *apiKeyRepository) Revoke(ctx context.Context, id string, revokedAt string) error {
+	return revokeAPIKey(r.db.WithContext(ctx), id, revokedAt)
+}
+
+// Rotate stores a replacement key and retires the old one in a single transaction. The old key
+// is revoked at retireAt, or with graceful set it keeps working and expires at retireAt instead.
+// It returns gorm.ErrRecordNotFound if there is no active key with the id.
+func (r *apiKeyRepository) Rotate(ctx context.Context, id string, replacement *domain.APIKey, retireAt string, graceful bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		column := "revoked_at"
+		if graceful {
+			column = "expires_at"
+		}
+
+		res := tx.Model(&domain.APIKey{}).
+			Where("id = ? AND revoked_at IS NULL", id).
+			Update(column, retireAt)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Create(replacement).Error
+	})
+}
+
+// RecordUsage stores the last use of each key in a single transaction
+func (r *apiKeyRepository) RecordUsage(ctx context.Context, usage []domain.APIKeyUsage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, u := range usage {
+			if err := tx.Model(&domain.APIKey{}).
+				Where("id = ?", u.KeyID).
+				Updates(map[string]any{"last_used_at": u.LastUsedAt, "last_used_ip": u.LastUsedIP}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func revokeAPIKey(db *gorm.DB, id string, revokedAt string) error {
+	result := db.Model(&domain.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
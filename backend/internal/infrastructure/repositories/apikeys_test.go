@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+func newTestAPIKey(prefix string) domain.APIKey {
+	return domain.APIKey{
+		ID:        uuid.NewString(),
+		OwnerID:   uuid.NewString(),
+		Name:      "test key",
+		Scopes:    domain.Scopes{domain.ScopeUsersRead, domain.ScopePostsWrite},
+		Prefix:    prefix,
+		Salt:      "salt",
+		Hash:      "hash",
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+func TestAPIKeyRepository_CreateAndRevoke(t *testing.T) {
+	apiKeysRepo := NewAPIKeyRepository(db)
+
+	key := newTestAPIKey("aaaa1111")
+	require.NoError(t, apiKeysRepo.Create(testCtx, &key))
+
+	found, err := apiKeysRepo.GetByPrefix(testCtx, key.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, key, *found)
+
+	_, err = apiKeysRepo.GetByPrefix(testCtx, "missing")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	revokedAt := time.Now().Format(time.RFC3339)
+	require.NoError(t, apiKeysRepo.Revoke(testCtx, key.ID, revokedAt))
+
+	found, err = apiKeysRepo.Get(testCtx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.RevokedAt)
+	assert.Equal(t, revokedAt, *found.RevokedAt)
+
+	// a revoked key can't be revoked again
+	assert.Equal(t, gorm.ErrRecordNotFound, apiKeysRepo.Revoke(testCtx, key.ID, revokedAt))
+}
+
+func TestAPIKeyRepository_Rotate(t *testing.T) {
+	apiKeysRepo := NewAPIKeyRepository(db)
+
+	key := newTestAPIKey("bbbb2222")
+	require.NoError(t, apiKeysRepo.Create(testCtx, &key))
+
+	replacement := newTestAPIKey("cccc3333")
+	require.NoError(t, apiKeysRepo.Rotate(testCtx, key.ID, &replacement, time.Now().Format(time.RFC3339), false))
+
+	old, err := apiKeysRepo.Get(testCtx, key.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, old.RevokedAt)
+
+	// rotating a revoked key stores nothing
+	another := newTestAPIKey("dddd4444")
+	assert.Equal(t, gorm.ErrRecordNotFound, apiKeysRepo.Rotate(testCtx, key.ID, &another, time.Now().Format(time.RFC3339), false))
+	_, err = apiKeysRepo.GetByPrefix(testCtx, another.Prefix)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	keys, err := apiKeysRepo.List(testCtx)
+	require.NoError(t, err)
+	var prefixes []string
+	for _, k := range keys {
+		prefixes = append(prefixes, k.Prefix)
+	}
+	assert.Contains(t, prefixes, replacement.Prefix)
+	assert.Contains(t, prefixes, key.Prefix)
+}
+
+func TestAPIKeyRepository_RotateWithGrace(t *testing.T) {
+	apiKeysRepo := NewAPIKeyRepository(db)
+
+	key := newTestAPIKey("eeee5555")
+	require.NoError(t, apiKeysRepo.Create(testCtx, &key))
+
+	graceUntil := time.Now().Add(time.Hour).Format(time.RFC3339)
+	replacement := newTestAPIKey("ffff6666")
+	require.NoError(t, apiKeysRepo.Rotate(testCtx, key.ID, &replacement, graceUntil, true))
+
+	// the old key stays active until the grace period ends
+	old, err := apiKeysRepo.Get(testCtx, key.ID)
+	require.NoError(t, err)
+	assert.Nil(t, old.RevokedAt)
+	require.NotNil(t, old.ExpiresAt)
+	assert.Equal(t, graceUntil, *old.ExpiresAt)
+
+	_, err = apiKeysRepo.GetByPrefix(testCtx, replacement.Prefix)
+	require.NoError(t, err)
+}
+
+func TestAPIKeyRepository_RecordUsage(t *testing.T) {
+	apiKeysRepo := NewAPIKeyRepository(db)
+
+	key := newTestAPIKey("abab7777")
+	require.NoError(t, apiKeysRepo.Create(testCtx, &key))
+
+	usedAt := time.Now().Format(time.RFC3339)
+	require.NoError(t, apiKeysRepo.RecordUsage(testCtx, []domain.APIKeyUsage{
+		{KeyID: key.ID, LastUsedAt: usedAt, LastUsedIP: "203.0.113.7"},
+		{KeyID: "missing", LastUsedAt: usedAt, LastUsedIP: "203.0.113.8"},
+	}))
+
+	found, err := apiKeysRepo.Get(testCtx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.LastUsedAt)
+	require.NotNil(t, found.LastUsedIP)
+	assert.Equal(t, usedAt, *found.LastUsedAt)
+	assert.Equal(t, "203.0.113.7", *found.LastUsedIP)
+}
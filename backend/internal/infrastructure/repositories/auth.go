@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+type authRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthRepository(db *gorm.DB) *authRepository {
+	return &authRepository{db: db}
+}
+
+func (r *authRepository) GetCredentials(ctx context.Context, userID string) (*domain.Credentials, error) {
+	var creds domain.Credentials
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&creds).Error; err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// SaveCredentials creates or replaces the user's password, clearing any failed logins and lockout
+func (r *authRepository) SaveCredentials(ctx context.Context, creds *domain.Credentials) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"password_hash", "failed_attempts", "locked_until", "updated_at"}),
+	}).Create(creds).Error
+}
+
+// RecordLoginFailure counts a failed login. Once maxAttempts fail in a row the account is locked
+// until lockedUntil and the count starts over, the returned bool reports whether that happened.
+func (r *authRepository) RecordLoginFailure(ctx context.Context, userID string, maxAttempts int, lockedUntil string) (bool, error) {
+	locked := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.Credentials{}).
+			Where("user_id = ?", userID).
+			Update("failed_attempts", gorm.Expr("failed_attempts + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var creds domain.Credentials
+		if err := tx.Where("user_id = ?", userID).First(&creds).Error; err != nil {
+			return err
+		}
+		if creds.FailedAttempts < maxAttempts {
+			return nil
+		}
+
+		locked = true
+		return tx.Model(&domain.Credentials{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]any{"failed_attempts": 0, "locked_until": lockedUntil}).Error
+	})
+	return locked, err
+}
+
+// ResetLoginFailures clears the failed login count and any lockout after a successful login
+func (r *authRepository) ResetLoginFailures(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Model(&domain.Credentials{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{"failed_attempts": 0, "locked_until": nil}).Error
+}
+
+func (r *authRepository) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *authRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken marks a token as used and stores its replacement in a single transaction.
+// It returns gorm.ErrRecordNotFound if the token was already used or revoked.
+func (r *authRepository) RotateRefreshToken(ctx context.Context, id string, usedAt string, replacement *domain.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+			Update("used_at", usedAt)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(replacement).Error
+	})
+}
+
+// RevokeSession revokes every token of a login session
+func (r *authRepository) RevokeSession(ctx context.Context, sessionID string, revokedAt string) error {
+	return r.db.WithContext(ctx).Model(&domain.RefreshToken{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// RevokeUserSessions revokes every token of every session the user has
+func (r *authRepository) RevokeUserSessions(ctx context.Context, userID string, revokedAt string) error {
+	return r.db.WithContext(ctx).Model(&domain.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
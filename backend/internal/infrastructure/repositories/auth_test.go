@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+func newTestRefreshToken(sessionID, userID string) domain.RefreshToken {
+	return domain.RefreshToken{
+		ID:        uuid.NewString(),
+		SessionID: sessionID,
+		UserID:    userID,
+		TokenHash: uuid.NewString(),
+		CreatedAt: time.Now().Format(time.RFC3339),
+		ExpiresAt: time.Now().Add(time.Hour).Format(time.RFC3339),
+	}
+}
+
+func TestAuthRepository_Credentials(t *testing.T) {
+	authRepo := NewAuthRepository(db)
+	userID := newTestUser(t).ID
+
+	_, err := authRepo.GetCredentials(testCtx, userID)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	creds := domain.Credentials{UserID: userID, PasswordHash: "hash-1", UpdatedAt: time.Now().Format(time.RFC3339)}
+	require.NoError(t, authRepo.SaveCredentials(testCtx, &creds))
+
+	lockedUntil := time.Now().Add(time.Minute).Format(time.RFC3339)
+	for attempt := 1; attempt <= 3; attempt++ {
+		locked, err := authRepo.RecordLoginFailure(testCtx, userID, 3, lockedUntil)
+		require.NoError(t, err)
+		assert.Equal(t, attempt == 3, locked)
+	}
+
+	found, err := authRepo.GetCredentials(testCtx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, found.FailedAttempts)
+	require.NotNil(t, found.LockedUntil)
+	assert.Equal(t, lockedUntil, *found.LockedUntil)
+
+	require.NoError(t, authRepo.ResetLoginFailures(testCtx, userID))
+	found, err = authRepo.GetCredentials(testCtx, userID)
+	require.NoError(t, err)
+	assert.Nil(t, found.LockedUntil)
+
+	// saving a new password replaces the hash and clears failed logins
+	_, err = authRepo.RecordLoginFailure(testCtx, userID, 3, lockedUntil)
+	require.NoError(t, err)
+	creds.PasswordHash = "hash-2"
+	require.NoError(t, authRepo.SaveCredentials(testCtx, &creds))
+
+	found, err = authRepo.GetCredentials(testCtx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "hash-2", found.PasswordHash)
+	assert.Equal(t, 0, found.FailedAttempts)
+
+	_, err = authRepo.RecordLoginFailure(testCtx, uuid.NewString(), 3, lockedUntil)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func TestAuthRepository_RotateRefreshToken(t *testing.T) {
+	authRepo := NewAuthRepository(db)
+	sessionID, userID := newTestUser(t).ID, uuid.NewString()
+
+	first := newTestRefreshToken(sessionID, userID)
+	require.NoError(t, authRepo.CreateRefreshToken(testCtx, &first))
+
+	found, err := authRepo.GetRefreshToken(testCtx, first.TokenHash)
+	require.NoError(t, err)
+	assert.Equal(t, first, *found)
+
+	usedAt := time.Now().Format(time.RFC3339)
+	second := newTestRefreshToken(sessionID, userID)
+	require.NoError(t, authRepo.RotateRefreshToken(testCtx, first.ID, usedAt, &second))
+
+	found, err = authRepo.GetRefreshToken(testCtx, first.TokenHash)
+	require.NoError(t, err)
+	require.NotNil(t, found.UsedAt)
+
+	// a used token cannot be rotated again and its replacement is not stored
+	third := newTestRefreshToken(sessionID, userID)
+	assert.Equal(t, gorm.ErrRecordNotFound, authRepo.RotateRefreshToken(testCtx, first.ID, usedAt, &third))
+	_, err = authRepo.GetRefreshToken(testCtx, third.TokenHash)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	require.NoError(t, authRepo.RevokeSession(testCtx, sessionID, usedAt))
+	found, err = authRepo.GetRefreshToken(testCtx, second.TokenHash)
+	require.NoError(t, err)
+	require.NotNil(t, found.RevokedAt)
+	assert.Equal(t, gorm.ErrRecordNotFound, authRepo.RotateRefreshToken(testCtx, second.ID, usedAt, &third))
+}
+
+func TestAuthRepository_RevokeUserSessions(t *testing.T) {
+	authRepo := NewAuthRepository(db)
+	userID := newTestUser(t).ID
+
+	first := newTestRefreshToken(uuid.NewString(), userID)
+	second := newTestRefreshToken(uuid.NewString(), userID)
+	other := newTestRefreshToken(uuid.NewString(), newTestUser(t).ID)
+	for _, token := range []*domain.RefreshToken{&first, &second, &other} {
+		require.NoError(t, authRepo.CreateRefreshToken(testCtx, token))
+	}
+
+	require.NoError(t, authRepo.RevokeUserSessions(testCtx, userID, time.Now().Format(time.RFC3339)))
+
+	for _, token := range []domain.RefreshToken{first, second} {
+		found, err := authRepo.GetRefreshToken(testCtx, token.TokenHash)
+		require.NoError(t, err)
+		assert.NotNil(t, found.RevokedAt)
+	}
+
+	found, err := authRepo.GetRefreshToken(testCtx, other.TokenHash)
+	require.NoError(t, err)
+	assert.Nil(t, found.RevokedAt)
+}
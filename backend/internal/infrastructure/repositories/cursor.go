@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+// applyCursor orders query by (created_at, id) newest first and restricts it to the rows
+// after the cursor in its direction. One extra row is fetched to detect further pages.
+// sqlite stores times as text in mixed formats and offsets, so as in Feed they are compared
+// on datetime(created_at), with empty and NULL times ordered before every other.
+func applyCursor(query *gorm.DB, table string, params domain.CursorParams) *gorm.DB {
+	createdAt, bound := fmt.Sprintf("COALESCE(datetime(%s.created_at), '')", table), "COALESCE(datetime(?), '')"
+	if isPostgres(query) {
+		createdAt, bound = table+".created_at", "?"
+	}
+	id := table + ".id"
+
+	cursor := params.Cursor
+	if cursor != nil && cursor.Direction == domain.CursorPrev {
+		query = query.
+			Where(fmt.Sprintf("(%s > %s OR (%s = %s AND %s > ?))", createdAt, bound, createdAt, bound, id), cursor.CreatedAt, cursor.CreatedAt, cursor.ID).
+			Order(createdAt + " ASC").
+			Order(id + " ASC")
+	} else {
+		if cursor != nil {
+			query = query.Where(fmt.Sprintf("(%s < %s OR (%s = %s AND %s < ?))", createdAt, bound, createdAt, bound, id), cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		}
+		query = query.
+			Order(createdAt + " DESC").
+			Order(id + " DESC")
+	}
+
+	return query.Limit(params.Limit + 1)
+}
+
+// cursorPage trims the extra row fetched by applyCursor, restores newest first order and
+// works out the cursors of the neighbouring pages
+func cursorPage[T any](rows []T, params domain.CursorParams, key func(T) domain.Cursor) ([]T, *domain.Cursor, *domain.Cursor) {
+	hasMore := len(rows) > params.Limit
+	if hasMore {
+		rows = rows[:params.Limit]
+	}
+
+	backwards := params.Cursor != nil && params.Cursor.Direction == domain.CursorPrev
+	if backwards {
+		slices.Reverse(rows)
+	}
+
+	if len(rows) == 0 {
+		return rows, nil, nil
+	}
+
+	var next, prev *domain.Cursor
+	if backwards || hasMore {
+		c := key(rows[len(rows)-1])
+		c.Direction = domain.CursorNext
+		next = &c
+	}
+	if (backwards && hasMore) || (!backwards && params.Cursor != nil) {
+		c := key(rows[0])
+		c.Direction = domain.CursorPrev
+		prev = &c
+	}
+
+	return rows, next, prev
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import "gorm.io/gorm"
+
+// isPostgres reports whether db runs on postgres, the repositories assume sqlite otherwise.
+// Most queries are portable, the two differ in how times are stored and in full-text search.
+func isPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
+
+// timeColumn returns the expression a time column is ordered on. sqlite stores times as text
+// with whatever offset they were written with, datetime() normalises them to UTC first.
+func timeColumn(db *gorm.DB, column string) string {
+	if isPostgres(db) {
+		return column
+	}
+	return "datetime(" + column + ")"
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+// uniqueConstraintPrefix is how sqlite reports the offending column, e.g.
+// "UNIQUE constraint failed: users.email"
+const uniqueConstraintPrefix = "UNIQUE constraint failed: "
+
+// pgUniqueViolation is the postgres error code of a UNIQUE violation
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err was raised by a UNIQUE constraint
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+	return false
+}
+
+// userConflictError maps a UNIQUE violation on the users table to ErrUserAlreadyExists,
+// attaching the conflicting column as a field error when it can be determined
+func userConflictError(err error) error {
+	column := uniqueColumn(err)
+	if column == "" {
+		return domain.ErrUserAlreadyExists
+	}
+
+	return domain.ErrUserAlreadyExists.WithFieldErrors(validation.Errors{
+		column: errors.New("already taken"),
+	})
+}
+
+// uniqueColumn returns the column of a UNIQUE violation on the users table, or "" if unknown.
+// Postgres names the constraint after it, e.g. users_email_key.
+func uniqueColumn(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		column, ok := strings.CutPrefix(pgErr.ConstraintName, "users_")
+		if !ok {
+			return ""
+		}
+		column, _ = strings.CutSuffix(column, "_key")
+		return column
+	}
+
+	msg := err.Error()
+	idx := strings.Index(msg, uniqueConstraintPrefix)
+	if idx == -1 {
+		return ""
+	}
+
+	column := strings.TrimPrefix(msg[idx+len(uniqueConstraintPrefix):], "users.")
+	if end := strings.IndexAny(column, " ,("); end != -1 {
+		column = column[:end]
+	}
+	return column
+}
@@ -2,8 +2,12 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/victor-nach/postr-backend/internal/domain"
 )
@@ -17,21 +21,412 @@ func NewPostRepository(db *gorm.DB) *postRepository {
 }
 
 func (r *postRepository) Create(ctx context.Context, post *domain.Post) error {
-	return r.db.WithContext(ctx).Create(post).Error
+	query := r.db.WithContext(ctx)
+	// a new post has no updated_at, it is left NULL since postgres has no empty time
+	if post.UpdatedAt == "" {
+		query = query.Omit("updated_at")
+	}
+	return query.Create(post).Error
+}
+
+type postAuthorJoin struct {
+	ID             string  `gorm:"column:id"`
+	UserID         string  `gorm:"column:user_id"`
+	Title          string  `gorm:"column:title"`
+	Body           string  `gorm:"column:body"`
+	CreatedAt      string  `gorm:"column:created_at"`
+	UpdatedAt      *string `gorm:"column:updated_at"`
+	AuthorName     string  `gorm:"column:author_name"`
+	AuthorUsername string  `gorm:"column:author_username"`
+}
+
+func (j postAuthorJoin) toPostDetail() domain.PostDetail {
+	detail := domain.PostDetail{
+		Post: domain.Post{
+			ID:        j.ID,
+			UserID:    j.UserID,
+			Title:     j.Title,
+			Body:      j.Body,
+			CreatedAt: j.CreatedAt,
+		},
+		Author: domain.Author{
+			ID:       j.UserID,
+			Name:     j.AuthorName,
+			Username: j.AuthorUsername,
+		},
+	}
+	if j.UpdatedAt != nil {
+		detail.UpdatedAt = *j.UpdatedAt
+	}
+	return detail
+}
+
+const postAuthorColumns = `
+	posts.id,
+	posts.user_id,
+	posts.title,
+	posts.body,
+	posts.created_at,
+	posts.updated_at,
+	users.name as author_name,
+	users.username as author_username
+`
+
+func (r *postRepository) Get(ctx context.Context, id string) (*domain.PostDetail, error) {
+	var result postAuthorJoin
+	if err := r.db.WithContext(ctx).
+		Table("posts").
+		Joins("JOIN users ON users.id = posts.user_id").
+		Where("posts.id = ?", id).
+		Select(postAuthorColumns).
+		First(&result).Error; err != nil {
+		return nil, err
+	}
+
+	detail := result.toPostDetail()
+	return &detail, nil
+}
+
+// postSortColumns maps the sortable fields to their columns, anything else is rejected
+var postSortColumns = map[string]string{
+	domain.PostSortCreatedAt: "posts.created_at",
+	domain.PostSortUpdatedAt: "posts.updated_at",
+	domain.PostSortTitle:     "posts.title",
 }
 
-func (r *postRepository) ListByUserID(ctx context.Context, userId string) ([]domain.Post, error) {
+func (r *postRepository) ListByUserID(ctx context.Context, params domain.ListPostsParams) (domain.PaginatedPosts, error) {
+	column, ok := postSortColumns[params.Sort]
+	if !ok {
+		return domain.PaginatedPosts{}, fmt.Errorf("unsupported sort field %q", params.Sort)
+	}
+	if params.Sort != domain.PostSortTitle {
+		column = timeColumn(r.db, column)
+	}
+	desc := params.Order != domain.SortAsc
+
+	var total int64
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Post{}).
+		Where("user_id = ?", params.UserID).
+		Count(&total).Error; err != nil {
+		return domain.PaginatedPosts{}, err
+	}
+
+	offset := (params.PageNumber - 1) * params.PageSize
+
+	posts := []domain.Post{}
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", params.UserID).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: column, Raw: true}, Desc: desc}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "posts.id", Raw: true}, Desc: desc}).
+		Offset(offset).
+		Limit(params.PageSize).
+		Find(&posts).Error; err != nil {
+		return domain.PaginatedPosts{}, err
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(params.PageSize)))
+	paginated := domain.PaginatedPosts{
+		Pagination: domain.Pagination{
+			CurrentPage: params.PageNumber,
+			TotalPages:  totalPages,
+			TotalSize:   int(total),
+		},
+		Posts: posts,
+	}
+
+	return paginated, nil
+}
+
+// ListByUserIDCursor returns a keyset paginated page of the user's posts, newest first
+func (r *postRepository) ListByUserIDCursor(ctx context.Context, userID string, params domain.CursorParams) (domain.PaginatedPosts, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).
+		Model(&domain.Post{}).
+		Where("user_id = ?", userID).
+		Count(&total).Error; err != nil {
+		return domain.PaginatedPosts{}, err
+	}
+
 	var posts []domain.Post
+	query := r.db.WithContext(ctx).Where("posts.user_id = ?", userID)
+	if err := applyCursor(query, "posts", params).Find(&posts).Error; err != nil {
+		return domain.PaginatedPosts{}, err
+	}
+
+	posts, next, prev := cursorPage(posts, params, func(p domain.Post) domain.Cursor {
+		return domain.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+	})
+	if posts == nil {
+		posts = []domain.Post{}
+	}
+
+	return domain.PaginatedPosts{
+		Pagination: domain.Pagination{
+			TotalPages: int(math.Ceil(float64(total) / float64(params.Limit))),
+			TotalSize:  int(total),
+		},
+		Posts:      posts,
+		NextCursor: next,
+		PrevCursor: prev,
+	}, nil
+}
+
+// Feed returns a page of posts across all users with their authors, newest first.
+// sqlite stores times as text, so they are compared on datetime(created_at), which normalises
+// the stored offsets and matches the idx_posts_feed index. Postgres compares the column itself.
+func (r *postRepository) Feed(ctx context.Context, params domain.FeedParams) (domain.PaginatedFeed, error) {
+	createdAt, bound := "datetime(posts.created_at)", "datetime(?)"
+	if isPostgres(r.db) {
+		createdAt, bound = "posts.created_at", "?"
+	}
+
+	filter := func(query *gorm.DB) *gorm.DB {
+		if params.Since != "" {
+			query = query.Where(createdAt+" >= "+bound, params.Since)
+		}
+		if params.Until != "" {
+			query = query.Where(createdAt+" < "+bound, params.Until)
+		}
+		return query
+	}
+
+	var total int64
+	if err := filter(r.db.WithContext(ctx).Model(&domain.Post{})).
+		Count(&total).Error; err != nil {
+		return domain.PaginatedFeed{}, err
+	}
+
+	offset := (params.PageNumber - 1) * params.PageSize
+
+	var rows []postAuthorJoin
+	if err := filter(r.db.WithContext(ctx).Table("posts")).
+		Joins("JOIN users ON users.id = posts.user_id").
+		Select(postAuthorColumns).
+		Order(createdAt + " DESC, posts.id DESC").
+		Offset(offset).
+		Limit(params.PageSize).
+		Find(&rows).Error; err != nil {
+		return domain.PaginatedFeed{}, err
+	}
+
+	posts := make([]domain.PostDetail, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, row.toPostDetail())
+	}
+
+	return domain.PaginatedFeed{
+		Pagination: domain.Pagination{
+			CurrentPage: params.PageNumber,
+			TotalPages:  int(math.Ceil(float64(total) / float64(params.PageSize))),
+			TotalSize:   int(total),
+		},
+		Posts: posts,
+	}, nil
+}
+
+type postSearchJoin struct {
+	Post    postAuthorJoin `gorm:"embedded"`
+	Snippet string         `gorm:"column:snippet"`
+}
+
+// Search runs a full-text search over post titles and bodies, with title matches ranked above
+// body matches. sqlite searches the posts_fts index and ranks by bm25, postgres searches the
+// posts.search column and ranks by ts_rank.
+func (r *postRepository) Search(ctx context.Context, params domain.SearchPostsParams) (domain.PaginatedSearchResults, error) {
+	search := searchSQLite
+	if isPostgres(r.db) {
+		search = searchPostgres
+	}
+
+	query, snippet, rank := search(r.db.WithContext(ctx), params.Query)
+	if query == nil {
+		return domain.PaginatedSearchResults{
+			Pagination: domain.Pagination{CurrentPage: params.PageNumber},
+			Posts:      []domain.PostSearchResult{},
+		}, nil
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return domain.PaginatedSearchResults{}, err
+	}
+
+	offset := (params.PageNumber - 1) * params.PageSize
+
+	var rows []postSearchJoin
+	if err := query.
+		Joins("JOIN users ON users.id = posts.user_id").
+		Select(postAuthorColumns + ", " + snippet + " as snippet").
+		Order(rank + ", posts.id").
+		Offset(offset).
+		Limit(params.PageSize).
+		Find(&rows).Error; err != nil {
+		return domain.PaginatedSearchResults{}, err
+	}
+
+	posts := make([]domain.PostSearchResult, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, domain.PostSearchResult{
+			PostDetail: row.Post.toPostDetail(),
+			Snippet:    row.Snippet,
+		})
+	}
+
+	return domain.PaginatedSearchResults{
+		Pagination: domain.Pagination{
+			CurrentPage: params.PageNumber,
+			TotalPages:  int(math.Ceil(float64(total) / float64(params.PageSize))),
+			TotalSize:   int(total),
+		},
+		Posts: posts,
+	}, nil
+}
+
+// searchSQLite returns the posts matching q along with the snippet and rank expressions,
+// or a nil query when q has no terms
+func searchSQLite(db *gorm.DB, q string) (*gorm.DB, string, string) {
+	match := ftsQuery(q)
+	if match == "" {
+		return nil, "", ""
+	}
+
+	query := db.
+		Table("posts_fts").
+		Joins("JOIN posts ON posts.rowid = posts_fts.rowid").
+		Where("posts_fts MATCH ?", match)
+	return query,
+		"snippet(posts_fts, -1, '<mark>', '</mark>', '...', 16)",
+		"bm25(posts_fts, 10.0, 1.0)"
+}
+
+// searchPostgres returns the posts matching q along with the snippet and rank expressions,
+// or a nil query when q has no terms. The snippet comes from the body when it matches and
+// from the title otherwise, as sqlite picks the best column.
+func searchPostgres(db *gorm.DB, q string) (*gorm.DB, string, string) {
+	match := tsQuery(q)
+	if match == "" {
+		return nil, "", ""
+	}
+
+	query := db.
+		Table("posts").
+		Joins("CROSS JOIN to_tsquery('english', ?) AS search_query", match).
+		Where("posts.search @@ search_query")
+	return query,
+		`CASE WHEN to_tsvector('english', posts.body) @@ search_query
+			THEN ts_headline('english', posts.body, search_query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=16, MinWords=5')
+			ELSE ts_headline('english', posts.title, search_query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=16, MinWords=5')
+		END`,
+		"ts_rank(posts.search, search_query) DESC"
+}
+
+// ftsQuery turns free text into an FTS5 query that matches rows containing every term.
+// Each term is quoted so user input can't inject FTS5 syntax, the last one also matches as a prefix.
+func ftsQuery(q string) string {
+	terms := strings.Fields(q)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	if len(terms) > 0 {
+		terms[len(terms)-1] += "*"
+	}
+	return strings.Join(terms, " ")
+}
+
+// tsQueryEscaper escapes a term for a quoted tsquery lexeme
+var tsQueryEscaper = strings.NewReplacer(`\`, `\\`, `'`, `''`)
+
+// tsQuery is ftsQuery for postgres, a tsquery that matches rows containing every term with
+// the last one also matching as a prefix
+func tsQuery(q string) string {
+	terms := strings.Fields(q)
+	for i, term := range terms {
+		terms[i] = "'" + tsQueryEscaper.Replace(term) + "'"
+	}
+	if len(terms) > 0 {
+		terms[len(terms)-1] += ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// Update applies the changes to the post and records its previous version as a revision,
+// both in a single transaction
+func (r *postRepository) Update(ctx context.Context, id string, update domain.PostUpdate) (*domain.Post, error) {
+	var post domain.Post
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// concurrent edits of the post wait for each other, so each one numbers its revision
+		// after the last. sqlite transactions lock the whole database as they begin.
+		query := tx
+		if isPostgres(tx) {
+			query = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		if err := query.Where("id = ?", id).First(&post).Error; err != nil {
+			return err
+		}
+
+		var latest int
+		if err := tx.Model(&domain.PostRevision{}).
+			Where("post_id = ?", id).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+
+		revision := domain.PostRevision{
+			PostID:    post.ID,
+			Version:   latest + 1,
+			Title:     post.Title,
+			Body:      post.Body,
+			CreatedAt: post.CreatedAt,
+		}
+		if post.UpdatedAt != "" {
+			revision.CreatedAt = post.UpdatedAt
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		if update.Title != nil {
+			post.Title = *update.Title
+		}
+		if update.Body != nil {
+			post.Body = *update.Body
+		}
+		post.UpdatedAt = update.UpdatedAt
+
+		return tx.Model(&domain.Post{}).
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"title":      post.Title,
+				"body":       post.Body,
+				"updated_at": post.UpdatedAt,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+// ListRevisions returns the previous versions of a post, oldest first
+func (r *postRepository) ListRevisions(ctx context.Context, postID string) ([]domain.PostRevision, error) {
+	var revisions []domain.PostRevision
 	if err := r.db.WithContext(ctx).
-		Where("user_id = ?", userId).
-		Find(&posts).
-		Order("created_at DESC").
-		Error; err != nil {
+		Where("post_id = ?", postID).
+		Order("version ASC").
+		Find(&revisions).Error; err != nil {
 		return nil, err
 	}
-	return posts, nil
+	return revisions, nil
 }
 
 func (r *postRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&domain.Post{}, "id = ?", id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("post_id = ?", id).Delete(&domain.PostRevision{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Post{}, "id = ?", id).Error
+	})
 }
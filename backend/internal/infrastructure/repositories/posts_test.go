@@ -3,18 +3,26 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/victor-nach/postr-backend/internal/config"
 	"github.com/victor-nach/postr-backend/internal/domain"
+	infradb "github.com/victor-nach/postr-backend/internal/infrastructure/db"
+	"github.com/victor-nach/postr-backend/migrations"
+	"github.com/victor-nach/postr-backend/pkg/migrator"
 
 	_ "modernc.org/sqlite"
 )
@@ -27,19 +35,46 @@ var (
 	testCtx = context.Background()
 )
 
+// TestMain runs the tests against an in-memory sqlite database, or against the postgres
+// database in TEST_POSTGRES_DSN when it is set, see make test-postgres. That database is
+// emptied first, so it must be one used only by the tests.
 func TestMain(m *testing.M) {
-	var err error
-	sqlDB, err = sql.Open("sqlite", ":memory:")
-	if err != nil {
-		log.Fatalf("Failed to open SQLite database: %v", err)
+	var (
+		dialect   = migrator.DialectSQLite
+		dialector gorm.Dialector
+		err       error
+	)
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		dialect = migrator.DialectPostgres
+		// opened as the app opens it, so times come back in UTC
+		sqlDB, err = infradb.OpenPostgres(dsn)
+		if err != nil {
+			log.Fatalf("Failed to open Postgres database: %v", err)
+		}
+		if _, err := sqlDB.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public"); err != nil {
+			log.Fatalf("Failed to empty Postgres database: %v", err)
+		}
+		dialector = postgres.New(postgres.Config{Conn: sqlDB})
+	} else {
+		sqlDB, err = sql.Open("sqlite", ":memory:")
+		if err != nil {
+			log.Fatalf("Failed to open SQLite database: %v", err)
+		}
+		// each connection to :memory: gets its own database
+		sqlDB.SetMaxOpenConns(1)
+		dialector = sqlite.Dialector{Conn: sqlDB}
 	}
 
-	db, err = gorm.Open(sqlite.Dialector{Conn: sqlDB}, &gorm.Config{})
+	db, err = gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		log.Fatalf("Failed to initialize GORM: %v", err)
 	}
 
-	if err := db.AutoMigrate(&domain.User{}, &domain.Post{}, domain.Address{}); err != nil {
+	fsys, err := migrations.For(dialect)
+	if err != nil {
+		log.Fatalf("Failed to read migrations: %v", err)
+	}
+	if err := migrator.MigrateFS(sqlDB, dialect, fsys); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
@@ -52,13 +87,27 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// newTestUser creates a user to own the rows of a test, postgres enforces the foreign keys
+func newTestUser(t *testing.T) domain.User {
+	id := uuid.NewString()
+	user := domain.User{
+		ID:        id,
+		Name:      "Test User",
+		Username:  "user-" + id,
+		Email:     id + "@example.com",
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	require.NoError(t, usersrepo.Create(testCtx, &user))
+	return user
+}
+
 func TestPostRepository_Create(t *testing.T) {
 	post := domain.Post{
 		ID:        uuid.NewString(),
-		UserID:    uuid.NewString(),
+		UserID:    newTestUser(t).ID,
 		Title:     "Test Title",
 		Body:      "Test Content body",
-		CreatedAt: time.Now().String(),
+		CreatedAt: time.Now().Format(time.RFC3339),
 	}
 
 	err := postsrepo.Create(testCtx, &post)
@@ -72,29 +121,378 @@ func TestPostRepository_Create(t *testing.T) {
 	assert.Equal(t, post.Body, found.Body)
 }
 
+func TestPostRepository_Get(t *testing.T) {
+	user := domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Post Author",
+		Username: "postauthor",
+		Email:    "postauthor@example.com",
+	}
+	require.NoError(t, usersrepo.Create(testCtx, &user))
+
+	post := domain.Post{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		Title:     "Detail Title",
+		Body:      "Detail body",
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	require.NoError(t, postsrepo.Create(testCtx, &post))
+
+	detail, err := postsrepo.Get(testCtx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, post, detail.Post)
+	assert.Equal(t, user.ID, detail.Author.ID)
+	assert.Equal(t, user.Name, detail.Author.Name)
+	assert.Equal(t, user.Username, detail.Author.Username)
+
+	_, err = postsrepo.Get(testCtx, "non-existent-id")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func TestPostRepository_Feed(t *testing.T) {
+	user := domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Feed Author",
+		Username: "feedauthor",
+		Email:    "feedauthor@example.com",
+	}
+	require.NoError(t, usersrepo.Create(testCtx, &user))
+
+	// The posts sit in their own window so other tests' posts stay out of it.
+	// 10:00+02:00 is earlier than 09:00Z even though it sorts later as text.
+	posts := []domain.Post{
+		{ID: uuid.NewString(), UserID: user.ID, Title: "Oldest", Body: "Body", CreatedAt: "2030-01-01T10:00:00+02:00"},
+		{ID: uuid.NewString(), UserID: user.ID, Title: "Middle", Body: "Body", CreatedAt: "2030-01-01T09:00:00Z"},
+		{ID: uuid.NewString(), UserID: user.ID, Title: "Newest", Body: "Body", CreatedAt: "2030-01-02T00:00:00Z"},
+	}
+	for i := range posts {
+		require.NoError(t, postsrepo.Create(testCtx, &posts[i]))
+	}
+
+	feed, err := postsrepo.Feed(testCtx, domain.FeedParams{Since: "2030-01-01T00:00:00Z", PageNumber: 1, PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, feed.Posts, 2)
+	assert.Equal(t, "Newest", feed.Posts[0].Title)
+	assert.Equal(t, "Middle", feed.Posts[1].Title)
+	assert.Equal(t, user.Username, feed.Posts[0].Author.Username)
+	assert.Equal(t, domain.Pagination{CurrentPage: 1, TotalPages: 2, TotalSize: 3}, feed.Pagination)
+
+	feed, err = postsrepo.Feed(testCtx, domain.FeedParams{Since: "2030-01-01T00:00:00Z", Until: "2030-01-01T09:00:00Z", PageNumber: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, feed.Posts, 1)
+	assert.Equal(t, "Oldest", feed.Posts[0].Title)
+}
+
+func TestPostRepository_Search(t *testing.T) {
+	user := domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Search Author",
+		Username: "searchauthor",
+		Email:    "searchauthor@example.com",
+	}
+	require.NoError(t, usersrepo.Create(testCtx, &user))
+
+	inTitle := domain.Post{ID: uuid.NewString(), UserID: user.ID, Title: "Zebra crossings", Body: "A note on roads", CreatedAt: time.Now().Format(time.RFC3339)}
+	inBody := domain.Post{ID: uuid.NewString(), UserID: user.ID, Title: "Animals", Body: "We saw a zebra at the zoo", CreatedAt: time.Now().Format(time.RFC3339)}
+	require.NoError(t, postsrepo.Create(testCtx, &inBody))
+	require.NoError(t, postsrepo.Create(testCtx, &inTitle))
+
+	results, err := postsrepo.Search(testCtx, domain.SearchPostsParams{Query: "zebra", PageNumber: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, results.Posts, 2)
+	assert.Equal(t, inTitle.ID, results.Posts[0].ID, "title matches rank first")
+	assert.Equal(t, inBody.ID, results.Posts[1].ID)
+	assert.Contains(t, results.Posts[1].Snippet, "<mark>zebra</mark>")
+	assert.Equal(t, user.Username, results.Posts[0].Author.Username)
+	assert.Equal(t, 2, results.Pagination.TotalSize)
+
+	// prefix match on the last term, every term must match
+	results, err = postsrepo.Search(testCtx, domain.SearchPostsParams{Query: "zoo zeb", PageNumber: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, results.Posts, 1)
+	assert.Equal(t, inBody.ID, results.Posts[0].ID)
+
+	// edits and deletes are mirrored into the index
+	title := "Horses"
+	_, err = postsrepo.Update(testCtx, inTitle.ID, domain.PostUpdate{Title: &title, UpdatedAt: time.Now().Format(time.RFC3339)})
+	require.NoError(t, err)
+	require.NoError(t, postsrepo.Delete(testCtx, inBody.ID))
+
+	results, err = postsrepo.Search(testCtx, domain.SearchPostsParams{Query: "zebra", PageNumber: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Empty(t, results.Posts)
+
+	// FTS5 syntax in the query is treated as text
+	_, err = postsrepo.Search(testCtx, domain.SearchPostsParams{Query: `title:"x OR (NEAR - *`, PageNumber: 1, PageSize: 10})
+	require.NoError(t, err)
+}
+
+func TestTSQuery(t *testing.T) {
+	assert.Equal(t, "'zoo' & 'zeb':*", tsQuery("zoo  zeb"))
+	assert.Equal(t, `'it''s' & 'a\\b' & '!(x':*`, tsQuery(`it's a\b !(x`))
+	assert.Empty(t, tsQuery("   "))
+}
+
+func TestPostRepository_Update(t *testing.T) {
+	post := domain.Post{
+		ID:        uuid.NewString(),
+		UserID:    newTestUser(t).ID,
+		Title:     "Original Title",
+		Body:      "Original body",
+		CreatedAt: "2025-01-01T10:00:00Z",
+	}
+	require.NoError(t, postsrepo.Create(testCtx, &post))
+
+	title := "Edited Title"
+	updated, err := postsrepo.Update(testCtx, post.ID, domain.PostUpdate{Title: &title, UpdatedAt: "2025-01-02T10:00:00Z"})
+	require.NoError(t, err)
+	assert.Equal(t, "Edited Title", updated.Title)
+	assert.Equal(t, "Original body", updated.Body)
+	assert.Equal(t, "2025-01-02T10:00:00Z", updated.UpdatedAt)
+
+	body := "Edited body"
+	_, err = postsrepo.Update(testCtx, post.ID, domain.PostUpdate{Body: &body, UpdatedAt: "2025-01-03T10:00:00Z"})
+	require.NoError(t, err)
+
+	var found domain.Post
+	require.NoError(t, db.WithContext(testCtx).First(&found, "id = ?", post.ID).Error)
+	assert.Equal(t, "Edited Title", found.Title)
+	assert.Equal(t, "Edited body", found.Body)
+	assert.Equal(t, "2025-01-03T10:00:00Z", found.UpdatedAt)
+
+	revisions, err := postsrepo.ListRevisions(testCtx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, domain.PostRevision{PostID: post.ID, Version: 1, Title: "Original Title", Body: "Original body", CreatedAt: "2025-01-01T10:00:00Z"}, revisions[0])
+	assert.Equal(t, domain.PostRevision{PostID: post.ID, Version: 2, Title: "Edited Title", Body: "Original body", CreatedAt: "2025-01-02T10:00:00Z"}, revisions[1])
+
+	_, err = postsrepo.Update(testCtx, "non-existent-id", domain.PostUpdate{Title: &title})
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
 func TestPostRepository_ListByUserID(t *testing.T) {
 	posts := []domain.Post{
-		{ID: uuid.NewString(), UserID: uuid.NewString(), Title: "Post 1", Body: "Body 1", CreatedAt: time.Now().String()},
-		{ID: uuid.NewString(), UserID: uuid.NewString(), Title: "Post 2", Body: "Body 2", CreatedAt: time.Now().String()},
+		{ID: uuid.NewString(), UserID: newTestUser(t).ID, Title: "Post 1", Body: "Body 1", CreatedAt: time.Now().Format(time.RFC3339)},
+		{ID: uuid.NewString(), UserID: newTestUser(t).ID, Title: "Post 2", Body: "Body 2", CreatedAt: time.Now().Format(time.RFC3339)},
 	}
-	require.NoError(t, db.WithContext(testCtx).Create(&posts).Error)
+	require.NoError(t, db.WithContext(testCtx).Omit("updated_at").Create(&posts).Error)
 
 	// List posts for the user
-	result, err := postsrepo.ListByUserID(testCtx, posts[0].UserID)
+	params := domain.ListPostsParams{UserID: posts[0].UserID, PageNumber: 1, PageSize: 10, Sort: domain.PostSortCreatedAt, Order: domain.SortDesc}
+	result, err := postsrepo.ListByUserID(testCtx, params)
+	require.NoError(t, err)
+	require.Len(t, result.Posts, 1)
+	assert.Equal(t, "Post 1", result.Posts[0].Title)
+}
+
+func TestPostRepository_ListByUserID_PaginatedAndSorted(t *testing.T) {
+	userID := newTestUser(t).ID
+	var posts []domain.Post
+	for i := 1; i <= 5; i++ {
+		posts = append(posts, domain.Post{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			Title:     fmt.Sprintf("Post %d", i),
+			Body:      "Body",
+			CreatedAt: fmt.Sprintf("2025-01-0%dT10:00:00Z", i),
+		})
+	}
+	require.NoError(t, db.WithContext(testCtx).Omit("updated_at").Create(&posts).Error)
+
+	params := domain.ListPostsParams{UserID: userID, PageNumber: 1, PageSize: 2, Sort: domain.PostSortCreatedAt, Order: domain.SortDesc}
+	result, err := postsrepo.ListByUserID(testCtx, params)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Pagination{CurrentPage: 1, TotalPages: 3, TotalSize: 5}, result.Pagination)
+	require.Len(t, result.Posts, 2)
+	assert.Equal(t, "Post 5", result.Posts[0].Title)
+	assert.Equal(t, "Post 4", result.Posts[1].Title)
+
+	params.PageNumber = 3
+	params.Order = domain.SortAsc
+	result, err = postsrepo.ListByUserID(testCtx, params)
+	require.NoError(t, err)
+	require.Len(t, result.Posts, 1)
+	assert.Equal(t, "Post 5", result.Posts[0].Title)
+
+	params.Sort = "body; DROP TABLE posts"
+	_, err = postsrepo.ListByUserID(testCtx, params)
+	assert.Error(t, err)
+}
+
+func TestPostRepository_ListByUserIDCursor(t *testing.T) {
+	userID := newTestUser(t).ID
+	var posts []domain.Post
+	for i := 1; i <= 3; i++ {
+		posts = append(posts, domain.Post{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			Title:     fmt.Sprintf("Post %d", i),
+			Body:      "Body",
+			CreatedAt: fmt.Sprintf("2025-02-0%dT10:00:00Z", i),
+		})
+	}
+	require.NoError(t, db.WithContext(testCtx).Omit("updated_at").Create(&posts).Error)
+
+	first, err := postsrepo.ListByUserIDCursor(testCtx, userID, domain.CursorParams{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first.Posts, 2)
+	assert.Equal(t, "Post 3", first.Posts[0].Title)
+	assert.Equal(t, "Post 2", first.Posts[1].Title)
+	require.NotNil(t, first.NextCursor)
+
+	// A post inserted ahead of the cursor does not shift the next page.
+	newer := domain.Post{ID: uuid.NewString(), UserID: userID, Title: "Post 4", Body: "Body", CreatedAt: "2025-02-04T10:00:00Z"}
+	require.NoError(t, postsrepo.Create(testCtx, &newer))
+
+	second, err := postsrepo.ListByUserIDCursor(testCtx, userID, domain.CursorParams{Cursor: first.NextCursor, Limit: 2})
 	require.NoError(t, err)
-	require.Len(t, result, 1)
-	assert.Equal(t, "Post 1", result[0].Title)
+	require.Len(t, second.Posts, 1)
+	assert.Equal(t, "Post 1", second.Posts[0].Title)
+	assert.Nil(t, second.NextCursor)
+	assert.Equal(t, 4, second.Pagination.TotalSize)
+}
+
+func TestPostRepository_ListByUserID_MixedTimes(t *testing.T) {
+	userID := newTestUser(t).ID
+	// oldest first: 08:00Z, 08:30Z, 09:00Z, 10:00Z
+	times := map[string]string{
+		"a": "2025-03-01T10:00:00+02:00",
+		"b": "2025-03-01T08:30:00Z",
+		"c": "2025-03-01 09:00:00",
+		"d": "2025-03-01T07:00:00-03:00",
+	}
+	for title, at := range times {
+		post := domain.Post{ID: uuid.NewString(), UserID: userID, Title: title, Body: "Body", CreatedAt: at, UpdatedAt: at}
+		require.NoError(t, db.WithContext(testCtx).Create(&post).Error)
+	}
+
+	for _, sort := range []string{domain.PostSortCreatedAt, domain.PostSortUpdatedAt} {
+		for order, want := range map[domain.SortOrder][]string{
+			domain.SortAsc:  {"a", "b", "c", "d"},
+			domain.SortDesc: {"d", "c", "b", "a"},
+		} {
+			var titles []string
+			for page := 1; page <= 2; page++ {
+				params := domain.ListPostsParams{UserID: userID, PageNumber: page, PageSize: 2, Sort: sort, Order: order}
+				result, err := postsrepo.ListByUserID(testCtx, params)
+				require.NoError(t, err)
+				for _, p := range result.Posts {
+					titles = append(titles, p.Title)
+				}
+			}
+			assert.Equal(t, want, titles, "%s %s", sort, order)
+		}
+	}
+}
+
+func TestPostRepository_ListByUserIDCursor_MixedTimes(t *testing.T) {
+	userID := newTestUser(t).ID
+	// newest first: 10:00Z, 09:00Z, 08:30Z, 08:00Z and, on sqlite, an empty time
+	times := map[string]string{
+		"a": "2025-03-01T07:00:00-03:00",
+		"b": "2025-03-01 09:00:00",
+		"c": "2025-03-01T08:30:00Z",
+		"d": "2025-03-01T10:00:00+02:00",
+	}
+	want := []string{"a", "b", "c", "d"}
+	if !isPostgres(db) {
+		times["e"] = ""
+		want = append(want, "e")
+	}
+	for title, createdAt := range times {
+		post := domain.Post{ID: uuid.NewString(), UserID: userID, Title: title, Body: "Body", CreatedAt: createdAt}
+		require.NoError(t, db.WithContext(testCtx).Omit("updated_at").Create(&post).Error)
+	}
+
+	var pages []domain.PaginatedPosts
+	params := domain.CursorParams{Limit: 2}
+	for {
+		page, err := postsrepo.ListByUserIDCursor(testCtx, userID, params)
+		require.NoError(t, err)
+		pages = append(pages, page)
+		if page.NextCursor == nil {
+			break
+		}
+		params.Cursor = page.NextCursor
+	}
+	var titles []string
+	for _, page := range pages {
+		for _, p := range page.Posts {
+			titles = append(titles, p.Title)
+		}
+	}
+	assert.Equal(t, want, titles)
+
+	// paging back from the last page returns the page before it
+	require.Len(t, pages, 2+len(want)%2)
+	back, err := postsrepo.ListByUserIDCursor(testCtx, userID, domain.CursorParams{Cursor: pages[len(pages)-1].PrevCursor, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, pages[len(pages)-2].Posts, back.Posts)
+}
+
+func TestPostRepository_UpdateConcurrently(t *testing.T) {
+	// the shared sqlite database has a single connection, so on sqlite the edits run against a
+	// database file of their own, opened as the app opens it
+	gormDB := db
+	if !isPostgres(db) {
+		var fileDB *sql.DB
+		var err error
+		gormDB, fileDB, err = infradb.New(&config.Config{
+			DBDriver:          config.DBDriverSQLite,
+			DBDSN:             "file:" + filepath.Join(t.TempDir(), "app.db"),
+			DBMaxOpenConns:    8,
+			DBMaxIdleConns:    8,
+			DBConnMaxLifetime: time.Hour,
+			DBConnMaxIdleTime: time.Minute,
+			DBBusyTimeout:     5 * time.Second,
+			DBJournalMode:     "WAL",
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { fileDB.Close() })
+	}
+	repo := NewPostRepository(gormDB)
+
+	id := uuid.NewString()
+	user := domain.User{ID: id, Name: "Test User", Username: "user-" + id, Email: id + "@example.com"}
+	require.NoError(t, NewUserRepository(gormDB).Create(testCtx, &user))
+	post := domain.Post{ID: uuid.NewString(), UserID: user.ID, Title: "Title", Body: "Body", CreatedAt: "2025-01-01T10:00:00Z"}
+	require.NoError(t, repo.Create(testCtx, &post))
+
+	const edits = 20
+	var wg sync.WaitGroup
+	for i := 1; i <= edits; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			title := fmt.Sprintf("Title %d", i)
+			_, err := repo.Update(testCtx, post.ID, domain.PostUpdate{Title: &title, UpdatedAt: time.Now().UTC().Format(time.RFC3339)})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// every edit kept the version before it, none was lost
+	revisions, err := repo.ListRevisions(testCtx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, edits)
+	titles := map[string]bool{}
+	for i, revision := range revisions {
+		assert.Equal(t, i+1, revision.Version)
+		titles[revision.Title] = true
+	}
+	assert.Len(t, titles, edits, "each revision should hold a different title")
 }
 
 func TestPostRepository_Delete(t *testing.T) {
 	post := domain.Post{
 		ID:        uuid.NewString(),
-		UserID:    uuid.NewString(),
+		UserID:    newTestUser(t).ID,
 		Title:     "To Delete",
 		Body:      "Body",
-		CreatedAt: time.Now().String(),
+		CreatedAt: time.Now().Format(time.RFC3339),
 	}
-	require.NoError(t, db.WithContext(testCtx).Create(&post).Error)
+	require.NoError(t, db.WithContext(testCtx).Omit("updated_at").Create(&post).Error)
 
 	// Delete the post
 	err := postsrepo.Delete(testCtx, post.ID)
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type quotaRepository struct {
+	db *gorm.DB
+}
+
+func NewQuotaRepository(db *gorm.DB) *quotaRepository {
+	return &quotaRepository{db: db}
+}
+
+// Consume adds one to the key's use of the quota in the period unless it has already reached
+// limit. It returns the use after the call and whether one was added, in a single statement so
+// concurrent requests cannot both take the last one.
+func (r *quotaRepository) Consume(ctx context.Context, keyID, kind, period string, limit int) (int, bool, error) {
+	var used []int
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO api_key_quotas (api_key_id, kind, period, used) VALUES (?, ?, ?, 1)
+		ON CONFLICT (api_key_id, kind, period) DO UPDATE SET used = api_key_quotas.used + 1
+		WHERE api_key_quotas.used < ?
+		RETURNING used`, keyID, kind, period, limit).Scan(&used).Error
+	if err != nil {
+		return 0, false, err
+	}
+	if len(used) == 1 {
+		return used[0], true, nil
+	}
+
+	current, err := r.Get(ctx, keyID, kind, period)
+	return current, false, err
+}
+
+// Refund gives back one use of the quota, for requests that ended up failing
+func (r *quotaRepository) Refund(ctx context.Context, keyID, kind, period string) error {
+	return r.db.WithContext(ctx).Exec(`
+		UPDATE api_key_quotas SET used = used - 1
+		WHERE api_key_id = ? AND kind = ? AND period = ? AND used > 0`, keyID, kind, period).Error
+}
+
+// Get returns the key's use of the quota in the period, zero if it has not been used
+func (r *quotaRepository) Get(ctx context.Context, keyID, kind, period string) (int, error) {
+	var used []int
+	err := r.db.WithContext(ctx).Table("api_key_quotas").
+		Where("api_key_id = ? AND kind = ? AND period = ?", keyID, kind, period).
+		Pluck("used", &used).Error
+	if err != nil || len(used) == 0 {
+		return 0, err
+	}
+	return used[0], nil
+}
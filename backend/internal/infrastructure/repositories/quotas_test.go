@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestQuotaKey creates the API key the quotas of a test are counted against
+func newTestQuotaKey(t *testing.T) string {
+	key := newTestAPIKey(uuid.NewString()[:8])
+	require.NoError(t, NewAPIKeyRepository(db).Create(testCtx, &key))
+	return key.ID
+}
+
+func TestQuotaRepository_ConsumeAndRefund(t *testing.T) {
+	quotasRepo := NewQuotaRepository(db)
+	keyID := newTestQuotaKey(t)
+
+	used, err := quotasRepo.Get(testCtx, keyID, "posts", "2025-01-31")
+	require.NoError(t, err)
+	assert.Equal(t, 0, used)
+
+	for want := 1; want <= 2; want++ {
+		used, ok, err := quotasRepo.Consume(testCtx, keyID, "posts", "2025-01-31", 2)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, want, used)
+	}
+
+	used, ok, err := quotasRepo.Consume(testCtx, keyID, "posts", "2025-01-31", 2)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 2, used)
+
+	// other kinds and periods are counted on their own
+	used, ok, err = quotasRepo.Consume(testCtx, keyID, "posts", "2025-02-01", 2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, used)
+
+	used, ok, err = quotasRepo.Consume(testCtx, keyID, "requests", "2025-01", 2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, used)
+
+	require.NoError(t, quotasRepo.Refund(testCtx, keyID, "posts", "2025-01-31"))
+	used, err = quotasRepo.Get(testCtx, keyID, "posts", "2025-01-31")
+	require.NoError(t, err)
+	assert.Equal(t, 1, used)
+
+	// refunding what was never used does nothing
+	require.NoError(t, quotasRepo.Refund(testCtx, keyID, "posts", "2024-12-31"))
+	used, err = quotasRepo.Get(testCtx, keyID, "posts", "2024-12-31")
+	require.NoError(t, err)
+	assert.Equal(t, 0, used)
+}
+
+func TestQuotaRepository_ConsumeConcurrently(t *testing.T) {
+	quotasRepo := NewQuotaRepository(db)
+	keyID := newTestQuotaKey(t)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := quotasRepo.Consume(testCtx, keyID, "requests", "2025-01", 5)
+			assert.NoError(t, err)
+			if ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, allowed)
+}
@@ -2,9 +2,14 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"math"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/victor-nach/postr-backend/internal/domain"
 )
@@ -23,6 +28,7 @@ type userAddressJoin  struct {
 	Username  string  `gorm:"column:username"`
 	Email     string  `gorm:"column:email"`
 	Phone     string  `gorm:"column:phone"`
+	CreatedAt *string `gorm:"column:created_at"`
 	AddressID *string `gorm:"column:address_id"`
 	Street    *string `gorm:"column:street"`
 	City      *string `gorm:"column:city"`
@@ -30,48 +36,74 @@ type userAddressJoin  struct {
 	Zipcode   *string `gorm:"column:zipcode"`
 }
 
+func (j userAddressJoin) toUser() domain.User {
+	user := domain.User{
+		ID:       j.ID,
+		Name:     j.Name,
+		Username: j.Username,
+		Email:    j.Email,
+		Phone:    j.Phone,
+	}
+	if j.CreatedAt != nil {
+		user.CreatedAt = *j.CreatedAt
+	}
+
+	if j.AddressID != nil {
+		user.Address = domain.Address{
+			ID:      *j.AddressID,
+			UserID:  j.ID,
+			Street:  *j.Street,
+			City:    *j.City,
+			State:   *j.State,
+			Zipcode: *j.Zipcode,
+		}
+	}
+
+	return user
+}
+
+const userAddressColumns = `
+	users.id,
+	users.name,
+	users.username,
+	users.email,
+	users.phone,
+	users.created_at,
+	addresses.id as address_id,
+	addresses.street,
+	addresses.city,
+	addresses.state,
+	addresses.zipcode
+`
+
 func (r *userRepository) Get(ctx context.Context, id string) (*domain.User, error) {
 	var result userAddressJoin
 	if err := r.db.WithContext(ctx).
 		Table("users").
 		Joins("LEFT JOIN addresses ON addresses.user_id = users.id").
 		Where("users.id = ?", id).
-		Select(`
-			users.id,
-			users.name,
-			users.username,
-			users.email,
-			users.phone,
-			addresses.id as address_id,
-			addresses.street,
-			addresses.city,
-			addresses.state,
-			addresses.zipcode
-		`).
+		Select(userAddressColumns).
 		First(&result).Error; err != nil {
 		return nil, err
 	}
 
-	user := &domain.User{
-		ID:       result.ID,
-		Name:     result.Name,
-		Username: result.Username,
-		Email:    result.Email,
-		Phone:    result.Phone,
-	}
+	user := result.toUser()
+	return &user, nil
+}
 
-	if result.AddressID != nil {
-		user.Address = domain.Address{
-			ID:      *result.AddressID,
-			UserID:  result.ID,
-			Street:  *result.Street,
-			City:    *result.City,
-			State:   *result.State,
-			Zipcode: *result.Zipcode,
-		}
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	var result userAddressJoin
+	if err := r.db.WithContext(ctx).
+		Table("users").
+		Joins("LEFT JOIN addresses ON addresses.user_id = users.id").
+		Where("users.username = ?", username).
+		Select(userAddressColumns).
+		First(&result).Error; err != nil {
+		return nil, err
 	}
 
-	return user, nil
+	user := result.toUser()
+	return &user, nil
 }
 
 func (r *userRepository) Count(ctx context.Context) (int, error) {
@@ -82,95 +114,76 @@ func (r *userRepository) Count(ctx context.Context) (int, error) {
 	return int(count), nil
 }
 
-// func (r *userRepository) List(ctx context.Context, pageNumber int, pageSize int) (domain.PaginatedUsers, error) {
-// 	var users []domain.User
-// 	var total int64
-
-// 	if err := r.db.WithContext(ctx).Model(&domain.User{}).Count(&total).Error; err != nil {
-// 		return domain.PaginatedUsers{}, err
-// 	}
-
-// 	offset := (pageNumber - 1) * pageSize
-// 	if err := r.db.WithContext(ctx).
-// 		Order("created_at DESC").
-// 		Offset(offset).
-// 		Limit(pageSize).
-// 		Find(&users).Error; err != nil {
-// 		return domain.PaginatedUsers{}, err
-// 	}
-
-// 	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
-// 	paginated := domain.PaginatedUsers{
-// 		Pagination: domain.Pagination{
-// 			CurrentPage: pageNumber,
-// 			TotalPages:  totalPages,
-// 			TotalSize:   int(total),
-// 		},
-// 		Users: users,
-// 	}
-
-// 	return paginated, nil
-// }
-
-func (r *userRepository) List(ctx context.Context, pageNumber int, pageSize int) (domain.PaginatedUsers, error) {
+// userSortColumns maps the sortable fields to their columns, anything else is rejected
+var userSortColumns = map[string]string{
+	domain.UserSortCreatedAt: "users.created_at",
+	domain.UserSortName:      "users.name",
+	domain.UserSortUsername:  "users.username",
+	domain.UserSortEmail:     "users.email",
+}
+
+// likeEscaper escapes the LIKE wildcards so a search term is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filterUsers applies the listing filters, all values are bound as parameters. Matches ignore
+// case, both sides are lowered since LIKE ignores case in sqlite but not in postgres.
+func filterUsers(query *gorm.DB, params domain.ListUsersParams) *gorm.DB {
+	query = query.Joins("LEFT JOIN addresses ON addresses.user_id = users.id")
+
+	if params.Query != "" {
+		query = query.Where(
+			`(LOWER(users.name) LIKE LOWER(@q) ESCAPE '\' OR LOWER(users.username) LIKE LOWER(@q) ESCAPE '\' OR LOWER(users.email) LIKE LOWER(@q) ESCAPE '\')`,
+			sql.Named("q", "%"+likeEscaper.Replace(params.Query)+"%"),
+		)
+	}
+	if params.City != "" {
+		query = query.Where("LOWER(addresses.city) = LOWER(?)", params.City)
+	}
+	if params.State != "" {
+		query = query.Where("LOWER(addresses.state) = LOWER(?)", params.State)
+	}
+
+	return query
+}
+
+func (r *userRepository) List(ctx context.Context, params domain.ListUsersParams) (domain.PaginatedUsers, error) {
+	column, ok := userSortColumns[params.Sort]
+	if !ok {
+		return domain.PaginatedUsers{}, fmt.Errorf("unsupported sort field %q", params.Sort)
+	}
+	if params.Sort == domain.UserSortCreatedAt {
+		column = timeColumn(r.db, column)
+	}
+	desc := params.Order != domain.SortAsc
+
 	var total int64
-	if err := r.db.WithContext(ctx).
-		Table("users").
+	if err := filterUsers(r.db.WithContext(ctx).Table("users"), params).
 		Count(&total).Error; err != nil {
 		return domain.PaginatedUsers{}, err
 	}
 
-	offset := (pageNumber - 1) * pageSize
+	offset := (params.PageNumber - 1) * params.PageSize
 
 	var results []userAddressJoin
-	if err := r.db.WithContext(ctx).
-		Table("users").
-		Joins("LEFT JOIN addresses ON addresses.user_id = users.id").
-		Order("users.id DESC").
+	if err := filterUsers(r.db.WithContext(ctx).Table("users"), params).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: column, Raw: true}, Desc: desc}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "users.id", Raw: true}, Desc: desc}).
 		Offset(offset).
-		Limit(pageSize).
-		Select(`
-			users.id,
-			users.name,
-			users.username,
-			users.email,
-			users.phone,
-			addresses.id as address_id,
-			addresses.street,
-			addresses.city,
-			addresses.state,
-			addresses.zipcode
-		`).
+		Limit(params.PageSize).
+		Select(userAddressColumns).
 		Scan(&results).Error; err != nil {
 		return domain.PaginatedUsers{}, err
 	}
 
-	var users []domain.User
+	users := []domain.User{}
 	for _, res := range results {
-		user := domain.User{
-			ID:       res.ID,
-			Name:     res.Name,
-			Username: res.Username,
-			Email:    res.Email,
-			Phone:    res.Phone,
-		}
-		if res.AddressID != nil {
-			user.Address = domain.Address{
-				ID:      *res.AddressID,
-				UserID:  res.ID,
-				Street:  *res.Street,
-				City:    *res.City,
-				State:   *res.State,
-				Zipcode: *res.Zipcode,
-			}
-		}
-		users = append(users, user)
+		users = append(users, res.toUser())
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+	totalPages := int(math.Ceil(float64(total) / float64(params.PageSize)))
 	paginated := domain.PaginatedUsers{
 		Pagination: domain.Pagination{
-			CurrentPage: pageNumber,
+			CurrentPage: params.PageNumber,
 			TotalPages:  totalPages,
 			TotalSize:   int(total),
 		},
@@ -180,6 +193,48 @@ func (r *userRepository) List(ctx context.Context, pageNumber int, pageSize int)
 	return paginated, nil
 }
 
+// ListByCursor returns a keyset paginated page of users, newest first
+func (r *userRepository) ListByCursor(ctx context.Context, params domain.CursorParams) (domain.PaginatedUsers, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).
+		Table("users").
+		Count(&total).Error; err != nil {
+		return domain.PaginatedUsers{}, err
+	}
+
+	query := r.db.WithContext(ctx).
+		Table("users").
+		Joins("LEFT JOIN addresses ON addresses.user_id = users.id").
+		Select(userAddressColumns)
+
+	var results []userAddressJoin
+	if err := applyCursor(query, "users", params).Scan(&results).Error; err != nil {
+		return domain.PaginatedUsers{}, err
+	}
+
+	results, next, prev := cursorPage(results, params, func(j userAddressJoin) domain.Cursor {
+		c := domain.Cursor{ID: j.ID}
+		if j.CreatedAt != nil {
+			c.CreatedAt = *j.CreatedAt
+		}
+		return c
+	})
+
+	users := []domain.User{}
+	for _, res := range results {
+		users = append(users, res.toUser())
+	}
+
+	return domain.PaginatedUsers{
+		Pagination: domain.Pagination{
+			TotalPages: int(math.Ceil(float64(total) / float64(params.Limit))),
+			TotalSize:  int(total),
+		},
+		Users:      users,
+		NextCursor: next,
+		PrevCursor: prev,
+	}, nil
+}
 
 func (r *userRepository) Validate(ctx context.Context, userID string) error {
 	var count int64
@@ -192,6 +247,114 @@ func (r *userRepository) Validate(ctx context.Context, userID string) error {
 	return nil
 }
 
+// Create inserts the user and, when present, its address in a single transaction
 func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		omit := []string{clause.Associations}
+		// without a created_at the column default applies, postgres has no empty time
+		if user.CreatedAt == "" {
+			omit = append(omit, "created_at")
+		}
+		if err := tx.Omit(omit...).Create(user).Error; err != nil {
+			return err
+		}
+
+		if user.Address.ID == "" {
+			return nil
+		}
+
+		user.Address.UserID = user.ID
+		return tx.Create(&user.Address).Error
+	})
+	if isUniqueViolation(err) {
+		return userConflictError(err)
+	}
+
+	return err
+}
+
+// Update overwrites the user's fields and syncs its address in a single transaction.
+// The address row is created when missing and removed when the user has no address.
+func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.User{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]any{
+				"name":     user.Name,
+				"username": user.Username,
+				"email":    user.Email,
+				"phone":    user.Phone,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if user.Address.ID == "" {
+			return tx.Where("user_id = ?", user.ID).Delete(&domain.Address{}).Error
+		}
+
+		var existing domain.Address
+		err := tx.Where("user_id = ?", user.ID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			user.Address.UserID = user.ID
+			return tx.Create(&user.Address).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		user.Address.ID = existing.ID
+		user.Address.UserID = user.ID
+		return tx.Model(&existing).Updates(map[string]any{
+			"street":  user.Address.Street,
+			"city":    user.Address.City,
+			"state":   user.Address.State,
+			"zipcode": user.Address.Zipcode,
+		}).Error
+	})
+	if isUniqueViolation(err) {
+		return userConflictError(err)
+	}
+
+	return err
+}
+
+// Delete removes the user and its address in a single transaction. Depending on the
+// mode the user's posts are either deleted or moved to opts.ReassignTo first.
+func (r *userRepository) Delete(ctx context.Context, id string, opts domain.DeleteUserOptions) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if opts.Mode == domain.UserDeleteReassign {
+			if err := tx.Model(&domain.Post{}).
+				Where("user_id = ?", id).
+				Update("user_id", opts.ReassignTo).Error; err != nil {
+				return err
+			}
+		}
+
+		userPosts := tx.Model(&domain.Post{}).Select("id").Where("user_id = ?", id)
+		if err := tx.Where("post_id IN (?)", userPosts).Delete(&domain.PostRevision{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", id).Delete(&domain.Post{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", id).Delete(&domain.Address{}).Error; err != nil {
+			return err
+		}
+
+		res := tx.Where("id = ?", id).Delete(&domain.User{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
 }
@@ -3,10 +3,12 @@ package repositories
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -48,6 +50,13 @@ func TestUserRepository_Get(t *testing.T) {
 	_, err = usersrepo.Get(testCtx, "non-existent-id")
 	assert.Error(t, err)
 	assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+	byUsername, err := usersrepo.GetByUsername(testCtx, user.Username)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, byUsername.ID)
+
+	_, err = usersrepo.GetByUsername(testCtx, "non-existent-username")
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
 }
 
 func TestUserRepository_Count(t *testing.T) {
@@ -73,7 +82,7 @@ func TestUserRepository_Count(t *testing.T) {
 			Phone:    "2222222222",
 		},
 	}
-	err = db.WithContext(testCtx).Create(&users).Error
+	err = db.WithContext(testCtx).Omit("created_at").Create(&users).Error
 	require.NoError(t, err)
 
 	count, err = usersrepo.Count(testCtx)
@@ -94,22 +103,161 @@ func TestUserRepository_List(t *testing.T) {
 			Phone:    fmt.Sprintf("12345678%02d", i),
 		})
 	}
-	err := db.WithContext(testCtx).Create(&users).Error
+	err := db.WithContext(testCtx).Omit("created_at").Create(&users).Error
 	require.NoError(t, err)
 
-	paginated, err := usersrepo.List(testCtx, 1, 2)
+	params := domain.ListUsersParams{PageNumber: 1, PageSize: 2, Sort: domain.UserSortCreatedAt, Order: domain.SortDesc}
+	paginated, err := usersrepo.List(testCtx, params)
 	require.NoError(t, err)
 	assert.Equal(t, 1, paginated.Pagination.CurrentPage)
 	assert.Equal(t, 3, paginated.Pagination.TotalPages)
 	assert.Equal(t, 5, paginated.Pagination.TotalSize)
 	assert.Len(t, paginated.Users, 2)
 
-	paginated, err = usersrepo.List(testCtx, 2, 2)
+	params.PageNumber = 2
+	paginated, err = usersrepo.List(testCtx, params)
 	require.NoError(t, err)
 	assert.Equal(t, 2, paginated.Pagination.CurrentPage)
 	assert.Len(t, paginated.Users, 2)
 }
 
+func TestUserRepository_List_FilteredAndSorted(t *testing.T) {
+	cleanUsers(t)
+
+	users := []domain.User{
+		{ID: uuid.NewString(), Name: "Jane Doe", Username: "janed", Email: "jane@example.com", Address: domain.Address{ID: uuid.NewString(), City: "Chicago", State: "IL"}},
+		{ID: uuid.NewString(), Name: "John Smith", Username: "jsmith", Email: "john@example.com", Address: domain.Address{ID: uuid.NewString(), City: "chicago", State: "IL"}},
+		{ID: uuid.NewString(), Name: "Ann Lee", Username: "ann_lee", Email: "ann@jane.dev", Address: domain.Address{ID: uuid.NewString(), City: "Houston", State: "TX"}},
+		{ID: uuid.NewString(), Name: "Annabel", Username: "annxlee", Email: "annabel@example.com"},
+	}
+	for i := range users {
+		require.NoError(t, usersrepo.Create(testCtx, &users[i]))
+	}
+
+	usernames := func(params domain.ListUsersParams) []string {
+		params.PageNumber, params.PageSize = 1, 10
+		if params.Sort == "" {
+			params.Sort = domain.UserSortUsername
+		}
+		paginated, err := usersrepo.List(testCtx, params)
+		require.NoError(t, err)
+		assert.Equal(t, len(paginated.Users), paginated.Pagination.TotalSize)
+
+		var out []string
+		for _, u := range paginated.Users {
+			out = append(out, u.Username)
+		}
+		return out
+	}
+
+	// q matches name, username or email
+	assert.Equal(t, []string{"ann_lee", "janed"}, usernames(domain.ListUsersParams{Query: "jane", Order: domain.SortAsc}))
+	// city and state match case-insensitively
+	assert.Equal(t, []string{"jsmith", "janed"}, usernames(domain.ListUsersParams{City: "CHICAGO", State: "il"}))
+	assert.Equal(t, []string{"janed"}, usernames(domain.ListUsersParams{Query: "doe", City: "Chicago"}))
+	// wildcards in q are matched literally
+	assert.Equal(t, []string{"ann_lee"}, usernames(domain.ListUsersParams{Query: "n_l"}))
+	assert.Empty(t, usernames(domain.ListUsersParams{Query: "%"}))
+
+	assert.Equal(t, []string{"ann@jane.dev", "annabel@example.com", "jane@example.com", "john@example.com"}, func() []string {
+		paginated, err := usersrepo.List(testCtx, domain.ListUsersParams{PageNumber: 1, PageSize: 10, Sort: domain.UserSortEmail, Order: domain.SortAsc})
+		require.NoError(t, err)
+		var out []string
+		for _, u := range paginated.Users {
+			out = append(out, u.Email)
+		}
+		return out
+	}())
+
+	_, err := usersrepo.List(testCtx, domain.ListUsersParams{PageNumber: 1, PageSize: 10, Sort: "phone"})
+	assert.Error(t, err)
+}
+
+func TestUserRepository_List_MixedTimes(t *testing.T) {
+	cleanUsers(t)
+
+	// oldest first: 08:00Z, 08:30Z, 09:00Z, 10:00Z
+	for i, createdAt := range []string{"2025-03-01T10:00:00+02:00", "2025-03-01T08:30:00Z", "2025-03-01 09:00:00", "2025-03-01T07:00:00-03:00"} {
+		user := domain.User{
+			ID:        fmt.Sprintf("user-%d", i),
+			Name:      fmt.Sprintf("User %d", i),
+			Username:  fmt.Sprintf("timeuser%d", i),
+			Email:     fmt.Sprintf("timeuser%d@example.com", i),
+			CreatedAt: createdAt,
+		}
+		require.NoError(t, usersrepo.Create(testCtx, &user))
+	}
+
+	for order, want := range map[domain.SortOrder][]string{
+		domain.SortAsc:  {"user-0", "user-1", "user-2", "user-3"},
+		domain.SortDesc: {"user-3", "user-2", "user-1", "user-0"},
+	} {
+		var ids []string
+		for page := 1; page <= 2; page++ {
+			params := domain.ListUsersParams{PageNumber: page, PageSize: 2, Sort: domain.UserSortCreatedAt, Order: order}
+			paginated, err := usersrepo.List(testCtx, params)
+			require.NoError(t, err)
+			for _, u := range paginated.Users {
+				ids = append(ids, u.ID)
+			}
+		}
+		assert.Equal(t, want, ids, order)
+	}
+}
+
+func TestUserRepository_ListByCursor(t *testing.T) {
+	cleanUsers(t)
+
+	// Two users share a created_at so the id breaks the tie.
+	var users []domain.User
+	for i, createdAt := range []string{"2025-01-01T00:00:00Z", "2025-01-02T00:00:00Z", "2025-01-02T00:00:00Z", "2025-01-03T00:00:00Z", "2025-01-04T00:00:00Z"} {
+		users = append(users, domain.User{
+			ID:        fmt.Sprintf("user-%d", i),
+			Name:      fmt.Sprintf("User %d", i),
+			Username:  fmt.Sprintf("cursoruser%d", i),
+			Email:     fmt.Sprintf("cursoruser%d@example.com", i),
+			CreatedAt: createdAt,
+		})
+	}
+	for i := range users {
+		require.NoError(t, usersrepo.Create(testCtx, &users[i]))
+	}
+
+	ids := func(page domain.PaginatedUsers) []string {
+		var out []string
+		for _, u := range page.Users {
+			out = append(out, u.ID)
+		}
+		return out
+	}
+
+	first, err := usersrepo.ListByCursor(testCtx, domain.CursorParams{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-4", "user-3"}, ids(first))
+	assert.Equal(t, 5, first.Pagination.TotalSize)
+	assert.Equal(t, 3, first.Pagination.TotalPages)
+	assert.Nil(t, first.PrevCursor)
+	require.NotNil(t, first.NextCursor)
+
+	second, err := usersrepo.ListByCursor(testCtx, domain.CursorParams{Cursor: first.NextCursor, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-2", "user-1"}, ids(second))
+	require.NotNil(t, second.NextCursor)
+	require.NotNil(t, second.PrevCursor)
+
+	last, err := usersrepo.ListByCursor(testCtx, domain.CursorParams{Cursor: second.NextCursor, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-0"}, ids(last))
+	assert.Nil(t, last.NextCursor)
+	require.NotNil(t, last.PrevCursor)
+
+	back, err := usersrepo.ListByCursor(testCtx, domain.CursorParams{Cursor: second.PrevCursor, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-4", "user-3"}, ids(back))
+	assert.Nil(t, back.PrevCursor)
+	require.NotNil(t, back.NextCursor)
+}
+
 func TestUserRepository_Validate(t *testing.T) {
 	cleanUsers(t)
 
@@ -133,7 +281,183 @@ func TestUserRepository_Validate(t *testing.T) {
 	assert.Equal(t, domain.ErrUserNotFound, err)
 }
 
+func TestUserRepository_Create(t *testing.T) {
+	cleanUsers(t)
+
+	user := domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Create User",
+		Username: "createuser",
+		Email:    "create@example.com",
+		Phone:    "1231231234",
+		Address: domain.Address{
+			ID:      uuid.NewString(),
+			Street:  "1 Create St",
+			City:    "Createville",
+			State:   "CR",
+			Zipcode: "11111",
+		},
+	}
+	err := usersrepo.Create(testCtx, &user)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, user.Address.UserID)
+
+	retrieved, err := usersrepo.Get(testCtx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Address, retrieved.Address)
+
+	// Same email, different username.
+	duplicate := domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Duplicate User",
+		Username: "duplicateuser",
+		Email:    user.Email,
+	}
+	err = usersrepo.Create(testCtx, &duplicate)
+	require.ErrorIs(t, err, domain.ErrUserAlreadyExists)
+
+	var derr domain.DomainError
+	require.ErrorAs(t, err, &derr)
+	assert.Contains(t, derr.FieldErrors, "email")
+
+	count, err := usersrepo.Count(testCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestUserRepository_Update(t *testing.T) {
+	cleanUsers(t)
+
+	user := domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Update User",
+		Username: "updateuser",
+		Email:    "update@example.com",
+	}
+	require.NoError(t, usersrepo.Create(testCtx, &user))
+
+	other := domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Other User",
+		Username: "otheruser",
+		Email:    "other@example.com",
+	}
+	require.NoError(t, usersrepo.Create(testCtx, &other))
+
+	// Update fields and upsert a missing address.
+	user.Name = "Updated Name"
+	user.Phone = "5555555555"
+	user.Address = domain.Address{
+		ID:      uuid.NewString(),
+		Street:  "9 Upsert Rd",
+		City:    "Upsertville",
+		State:   "UP",
+		Zipcode: "99999",
+	}
+	require.NoError(t, usersrepo.Update(testCtx, &user))
+
+	retrieved, err := usersrepo.Get(testCtx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated Name", retrieved.Name)
+	assert.Equal(t, "5555555555", retrieved.Phone)
+	assert.Equal(t, user.Address, retrieved.Address)
+
+	// Update the existing address in place.
+	addressID := user.Address.ID
+	user.Address.ID = uuid.NewString()
+	user.Address.City = "Movedville"
+	require.NoError(t, usersrepo.Update(testCtx, &user))
+
+	retrieved, err = usersrepo.Get(testCtx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, addressID, retrieved.Address.ID)
+	assert.Equal(t, "Movedville", retrieved.Address.City)
+
+	// Remove the address.
+	user.Address = domain.Address{}
+	require.NoError(t, usersrepo.Update(testCtx, &user))
+
+	retrieved, err = usersrepo.Get(testCtx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, retrieved.Address.ID)
+
+	// Conflict with another user's email.
+	user.Email = other.Email
+	err = usersrepo.Update(testCtx, &user)
+	require.ErrorIs(t, err, domain.ErrUserAlreadyExists)
+
+	// Unknown user.
+	missing := domain.User{ID: "non-existent-id", Name: "Nobody", Username: "nobody", Email: "nobody@example.com"}
+	err = usersrepo.Update(testCtx, &missing)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func TestUserRepository_Delete(t *testing.T) {
+	cleanUsers(t)
+
+	newUser := func(name string) domain.User {
+		user := domain.User{
+			ID:       uuid.NewString(),
+			Name:     name,
+			Username: name,
+			Email:    name + "@example.com",
+			Address: domain.Address{
+				ID:      uuid.NewString(),
+				Street:  "1 Delete St",
+				City:    "Deleteville",
+				State:   "DL",
+				Zipcode: "00000",
+			},
+		}
+		require.NoError(t, usersrepo.Create(testCtx, &user))
+
+		post := domain.Post{ID: uuid.NewString(), UserID: user.ID, Title: "Post", Body: "Body", CreatedAt: time.Now().Format(time.RFC3339)}
+		require.NoError(t, postsrepo.Create(testCtx, &post))
+		return user
+	}
+
+	countRows := func(model any, userID string) int64 {
+		var count int64
+		require.NoError(t, db.WithContext(testCtx).Model(model).Where("user_id = ?", userID).Count(&count).Error)
+		return count
+	}
+
+	// Cascade removes the address and posts.
+	cascaded := newUser("cascaded")
+	err := usersrepo.Delete(testCtx, cascaded.ID, domain.DeleteUserOptions{Mode: domain.UserDeleteCascade})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ErrUserNotFound, usersrepo.Validate(testCtx, cascaded.ID))
+	assert.Zero(t, countRows(&domain.Address{}, cascaded.ID))
+	assert.Zero(t, countRows(&domain.Post{}, cascaded.ID))
+
+	// Reassign moves posts to the target user.
+	source := newUser("source")
+	target := newUser("target")
+	err = usersrepo.Delete(testCtx, source.ID, domain.DeleteUserOptions{Mode: domain.UserDeleteReassign, ReassignTo: target.ID})
+	require.NoError(t, err)
+	assert.Zero(t, countRows(&domain.Address{}, source.ID))
+	assert.Zero(t, countRows(&domain.Post{}, source.ID))
+	assert.Equal(t, int64(2), countRows(&domain.Post{}, target.ID))
+
+	// Unknown user.
+	err = usersrepo.Delete(testCtx, "non-existent-id", domain.DeleteUserOptions{Mode: domain.UserDeleteCascade})
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func TestUserConflictError(t *testing.T) {
+	err := userConflictError(&pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_email_key"})
+	var derr domain.DomainError
+	require.ErrorAs(t, err, &derr)
+	assert.Equal(t, map[string]string{"email": "already taken"}, derr.FieldErrors)
+
+	assert.True(t, isUniqueViolation(fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: pgUniqueViolation})))
+	assert.False(t, isUniqueViolation(&pgconn.PgError{Code: "23503"}))
+}
+
 func cleanUsers(t *testing.T) {
-	err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&domain.User{}).Error
+	err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&domain.Address{}).Error
+	require.NoError(t, err)
+
+	err = db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&domain.User{}).Error
 	require.NoError(t, err)
 }
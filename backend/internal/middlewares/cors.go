@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// corsHandler is the CORS middleware built for a set of allowed origins
+type corsHandler struct {
+	origins []string
+	handler gin.HandlerFunc
+}
+
+// CORSMiddleware answers preflight requests and sets the CORS headers for the allowed origins.
+// The handler is rebuilt when a reload changes the origins.
+func (m *Service) CORSMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origins := m.config.Current().CORSAllowedOrigins
+
+		h := m.cors.Load()
+		if h == nil || !slices.Equal(h.origins, origins) {
+			h = &corsHandler{origins: origins, handler: cors.New(corsConfig(origins))}
+			m.cors.Store(h)
+		}
+		h.handler(c)
+	}
+}
+
+func corsConfig(origins []string) cors.Config {
+	return cors.Config{
+		AllowOrigins:  origins,
+		AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
+		AllowHeaders:  []string{"Origin", "Content-Length", "Content-Type", "X-API-Key", "Authorization"},
+		ExposeHeaders: []string{"Content-Length", HeaderRateLimitLimit, HeaderRateLimitRemaining, HeaderRateLimitReset, HeaderRetryAfter},
+		MaxAge:        12 * time.Hour,
+	}
+}
@@ -1,46 +1,98 @@
 package middlewares
 
 import (
+	"context"
+	"errors"
+	"math"
 	"net/http"
-	"sync"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
 
 	"github.com/victor-nach/postr-backend/internal/config"
 	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/pkg/jwtauth"
+	"github.com/victor-nach/postr-backend/pkg/ratelimit"
 )
 
 const (
-	UserIDKey = "user_id"
+	UserIDKey   = "user_id"
+	RoleKey     = "role"
+	APIKeyIDKey = "api_key_id"
+	ScopesKey   = "scopes"
+	// AuthSkippedKey is set when the caller was not authenticated, see AuthMiddleware
+	AuthSkippedKey = "auth_skipped"
+)
+
+// refundTimeout bounds giving back a quota, which runs after the request may have been cancelled
+const refundTimeout = 5 * time.Second
+
+// Rate limit headers, Reset and Retry-After are in seconds from now
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
 )
 
 type Service struct {
-	logger       *zap.Logger
-	config       *config.Config
-	userLimiters map[string]*rate.Limiter
-	mu           sync.Mutex
+	logger  *zap.Logger
+	config  *config.Store
+	apiKeys domain.APIKeyService
+	tokens  *jwtauth.Verifier
+	usage   UsageRecorder
+	limiter LimiterStore
+	quotas  domain.QuotaService
+	cors    atomic.Pointer[corsHandler]
+}
+
+// UsageRecorder notes that an API key was used, it must return without waiting on storage
+type UsageRecorder interface {
+	Record(keyID, ip string)
+}
+
+// LimiterStore holds the rate limit buckets, keyed by the caller
+type LimiterStore interface {
+	Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
 }
 
-func New(logger *zap.Logger, cfg *config.Config) *Service {
+// New creates the middlewares, tokens may be nil when bearer tokens are not accepted.
+// The configuration is read from cfg on every request, so reloads apply straight away.
+func New(logger *zap.Logger, cfg *config.Store, apiKeys domain.APIKeyService, tokens *jwtauth.Verifier, usage UsageRecorder, limiter LimiterStore, quotas domain.QuotaService) *Service {
 	return &Service{
-		logger:       logger,
-		config:       cfg,
-		userLimiters: make(map[string]*rate.Limiter),
+		logger:  logger,
+		config:  cfg,
+		apiKeys: apiKeys,
+		tokens:  tokens,
+		usage:   usage,
+		limiter: limiter,
+		quotas:  quotas,
 	}
 }
 
-// AuthMiddleware checks for the X-API-Key header against valid keys
+// AuthMiddleware authenticates an Authorization bearer token or else the X-API-Key header
 func (m *Service) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if m.config.AppEnv == config.DevEnv {
+		if m.config.Current().AppEnv == config.DevEnv {
 			m.logger.Info("skipping API key check in development mode")
 			c.Set(UserIDKey, "dev-user")
+			c.Set(RoleKey, string(domain.RoleAdmin))
+			c.Set(ScopesKey, domain.Scopes{domain.ScopeAdmin})
+			c.Set(AuthSkippedKey, true)
 			c.Next()
 			return
 		}
 
+		if scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+			m.authenticateToken(c, strings.TrimSpace(token))
+			return
+		}
+
 		apiKey := c.GetHeader("X-API-Key")
 		if apiKey == "" {
 			m.logger.Error("missing API key")
@@ -49,43 +101,112 @@ func (m *Service) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		var userID string
-		authorized := false
-		for id, keyValue := range m.config.APIKeys {
-			if keyValue == apiKey {
-				userID = id
-				authorized = true
-				break
+		key, err := m.apiKeys.Authenticate(c.Request.Context(), apiKey)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidAPIKey) || errors.Is(err, domain.ErrAPIKeyExpired) {
+				m.logger.Error("invalid API key", zap.Error(err))
+				c.JSON(http.StatusUnauthorized, err)
+				c.Abort()
+				return
 			}
-		}
 
-		if !authorized {
-			m.logger.Error("invalid API key", zap.String("api_key", apiKey))
-			c.JSON(http.StatusUnauthorized, domain.ErrInvalidAPIKey)
+			m.logger.Error("error authenticating API key", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, domain.ErrInternalServer)
 			c.Abort()
 			return
 		}
 
-		c.Set(UserIDKey, userID)
-		m.logger.Info("user authenticated", zap.String("user_id", userID))
+		c.Set(UserIDKey, key.OwnerID)
+		c.Set(RoleKey, string(key.Scopes.Role()))
+		c.Set(APIKeyIDKey, key.ID)
+		c.Set(ScopesKey, key.Scopes)
+		m.usage.Record(key.ID, c.ClientIP())
+		m.logger.Info("user authenticated", zap.String("user_id", key.OwnerID), zap.Any("scopes", key.Scopes), zap.String("api_key_id", key.ID))
 
 		c.Next()
 	}
 }
 
-// RateLimitMiddleware applies a per-user rate limit based on the userID from the context
+// authenticateToken verifies a bearer JWT, its sub claim is the user the request acts as
+func (m *Service) authenticateToken(c *gin.Context, token string) {
+	if m.tokens == nil {
+		m.logger.Error("bearer token sent but JWT authentication is not configured")
+		c.JSON(http.StatusUnauthorized, domain.ErrInvalidToken)
+		c.Abort()
+		return
+	}
+
+	claims, err := m.tokens.Verify(token)
+	if err != nil {
+		m.logger.Error("invalid bearer token", zap.Error(err))
+		c.JSON(http.StatusUnauthorized, domain.ErrInvalidToken)
+		c.Abort()
+		return
+	}
+
+	scopes := tokenScopes(claims)
+	c.Set(UserIDKey, claims.Subject)
+	c.Set(RoleKey, string(scopes.Role()))
+	c.Set(ScopesKey, scopes)
+	m.logger.Info("user authenticated", zap.String("user_id", claims.Subject), zap.Any("scopes", scopes), zap.String("auth", "jwt"))
+
+	c.Next()
+}
+
+// tokenScopes keeps the known scopes of a scope claim, tokens without one get domain.UserScopes.
+// An empty claim grants no scopes.
+func tokenScopes(claims *jwtauth.Claims) domain.Scopes {
+	if !claims.HasScope {
+		return domain.UserScopes
+	}
+
+	scopes := domain.Scopes{}
+	for _, scope := range claims.Scopes {
+		if slices.Contains(domain.AllScopes, domain.Scope(scope)) {
+			scopes = append(scopes, domain.Scope(scope))
+		}
+	}
+	return scopes
+}
+
+// RequireScope rejects callers whose key does not grant the scope, it must run after AuthMiddleware
+func (m *Service) RequireScope(scope domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, _ := c.Value(ScopesKey).(domain.Scopes)
+		if !scopes.Allows(scope) {
+			m.logger.Warn("missing scope", zap.String("user_id", c.GetString(UserIDKey)), zap.String("scope", string(scope)))
+			c.JSON(http.StatusForbidden, domain.ErrInsufficientScopeFor(scope))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimitMiddleware applies the rate limit policy matching the route group, method and the
+// caller's tier. Authenticated callers are limited per user, anonymous callers and those whose
+// authentication was skipped per client IP. Every response carries the X-RateLimit headers,
+// rejected requests also get Retry-After.
 func (m *Service) RateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, exists := c.Get(UserIDKey)
-		if !exists {
-			m.logger.Warn("user_id not found in context for rate limiting")
+		tier, caller := rateLimitCaller(c)
+		policy := m.config.Current().RateLimitPolicies.Match(routeGroup(c), c.Request.Method, tier)
+
+		res, err := m.limiter.Take(c.Request.Context(), policy.Name+":"+caller, policy.Limit())
+		if err != nil {
+			// a store we cannot reach should not take the API down with it
+			m.logger.Error("error checking rate limit, allowing request", zap.Error(err))
 			c.Next()
 			return
 		}
 
-		limiter := m.getLimiter(userID.(string))
-		if !limiter.Allow() {
-			m.logger.Warn("rate limit exceeded", zap.String("user_id", userID.(string)))
+		setRateLimitHeaders(c, res)
+		if !res.Allowed {
+			m.logger.Warn("rate limit exceeded", zap.String("caller", caller), zap.String("policy", policy.Name), zap.Duration("retry_after", res.RetryAfter))
+			if res.RetryAfter > 0 {
+				c.Header(HeaderRetryAfter, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			}
 			c.JSON(http.StatusTooManyRequests, domain.ErrTooManyRequests)
 			c.Abort()
 			return
@@ -95,18 +216,77 @@ func (m *Service) RateLimitMiddleware() gin.HandlerFunc {
 	}
 }
 
-// getLimiter retrieves or creates a rate.Limiter for the given user
-func (m *Service) getLimiter(userID string) *rate.Limiter {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	limiter, exists := m.userLimiters[userID]
-	if !exists {
-		// Create a new limiter if none exists for this user
-		r := rate.Limit(m.config.RateLimtPS)
-		limiter = rate.NewLimiter(r, m.config.RateLimtPS)
-		m.userLimiters[userID] = limiter
+// rateLimitCaller returns the caller's tier and the key its requests are counted under
+func rateLimitCaller(c *gin.Context) (string, string) {
+	userID := c.GetString(UserIDKey)
+	if userID == "" || c.GetBool(AuthSkippedKey) {
+		return ratelimit.TierAnonymous, "ip:" + c.ClientIP()
+	}
+	if c.GetString(RoleKey) == string(domain.RoleAdmin) {
+		return ratelimit.TierAdmin, "user:" + userID
+	}
+	return ratelimit.TierUser, "user:" + userID
+}
+
+// routeGroup is the first segment of the matched route, such as posts for /posts/:id
+func routeGroup(c *gin.Context) string {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	group, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return group
+}
+
+// RequireQuota counts the request against the API key's quota of the kind and rejects it once
+// the quota is used up. Requests that fail with a server error are given back, client errors
+// such as invalid input still count, so retrying bad requests cannot be done for free. Requests
+// made without a key, with a bearer token or in development, are not counted.
+func (m *Service) RequireQuota(kind domain.QuotaKind) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keyID := c.GetString(APIKeyIDKey)
+		if keyID == "" {
+			c.Next()
+			return
+		}
+
+		usage, err := m.quotas.Consume(c.Request.Context(), keyID, kind)
+		if err != nil {
+			if errors.Is(err, domain.ErrQuotaExceeded) {
+				m.logger.Warn("quota exceeded", zap.String("api_key_id", keyID), zap.String("kind", string(kind)))
+				if resetsAt, perr := time.Parse(time.RFC3339, usage.ResetsAt); perr == nil {
+					c.Header(HeaderRetryAfter, strconv.Itoa(ceilSeconds(time.Until(resetsAt))))
+				}
+				c.JSON(http.StatusTooManyRequests, err)
+				c.Abort()
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			// the client may have gone away, the refund must still happen
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), refundTimeout)
+			defer cancel()
+			if err := m.quotas.Refund(ctx, keyID, kind, usage.Period); err != nil {
+				m.logger.Error("error refunding quota", zap.Error(err), zap.String("api_key_id", keyID), zap.String("kind", string(kind)))
+			}
+		}
 	}
+}
+
+func setRateLimitHeaders(c *gin.Context, res ratelimit.Result) {
+	c.Header(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+	c.Header(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+	c.Header(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(res.Reset)))
+}
 
-	return limiter
+// ceilSeconds rounds up so clients never retry before the limiter allows them to
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
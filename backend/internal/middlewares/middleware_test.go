@@ -0,0 +1,266 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/internal/config"
+	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/internal/domain/mocks"
+	"github.com/victor-nach/postr-backend/pkg/jwtauth"
+	"github.com/victor-nach/postr-backend/pkg/ratelimit"
+)
+
+// newRateLimitRouter mounts the rate limit middleware behind a stand in for AuthMiddleware
+// that authenticates the user named in the X-User header, if any
+func newRateLimitRouter(policies ratelimit.Policies) *gin.Engine {
+	cfg := &config.Config{RateLimitPolicies: policies, CORSAllowedOrigins: []string{"*"}}
+	return newConfigRouter(config.NewStore(cfg, "", zap.NewNop()))
+}
+
+// newConfigRouter is newRateLimitRouter with the CORS middleware, reading the configuration from cfg
+func newConfigRouter(cfg *config.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	mws := New(zap.NewNop(), cfg, nil, nil, nil, ratelimit.NewMemoryStore(ratelimit.Options{}), nil)
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Current().TrustedProxies); err != nil {
+		panic(err)
+	}
+	router.Use(mws.CORSMiddleware(), func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set(UserIDKey, user)
+			c.Set(RoleKey, string(domain.RoleUser))
+		}
+	}, mws.RateLimitMiddleware())
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/posts", ok)
+	router.POST("/posts", ok)
+	router.GET("/users/:id", ok)
+	return router
+}
+
+func doRequest(t *testing.T, router *gin.Engine, method, path, user, ip string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(method, path, nil)
+	require.NoError(t, err)
+	req.RemoteAddr = ip + ":1234"
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware_Headers(t *testing.T) {
+	router := newRateLimitRouter(ratelimit.SinglePolicy(2))
+
+	w := doRequest(t, router, "GET", "/posts", "user-1", "10.0.0.1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "2", w.Header().Get(HeaderRateLimitLimit))
+	require.Equal(t, "1", w.Header().Get(HeaderRateLimitRemaining))
+	require.Equal(t, "1", w.Header().Get(HeaderRateLimitReset))
+	require.Empty(t, w.Header().Get(HeaderRetryAfter))
+
+	w = doRequest(t, router, "GET", "/posts", "user-1", "10.0.0.1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "0", w.Header().Get(HeaderRateLimitRemaining))
+
+	w = doRequest(t, router, "GET", "/posts", "user-1", "10.0.0.1")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "0", w.Header().Get(HeaderRateLimitRemaining))
+	retryAfter, err := strconv.Atoi(w.Header().Get(HeaderRetryAfter))
+	require.NoError(t, err)
+	require.Equal(t, 1, retryAfter)
+
+	// other users have their own limit
+	w = doRequest(t, router, "GET", "/posts", "user-2", "10.0.0.1")
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRateLimitMiddleware_Policies(t *testing.T) {
+	router := newRateLimitRouter(ratelimit.Policies{
+		Default: ratelimit.Policy{Name: "default", Rate: 1, Burst: 2},
+		Rules: []ratelimit.Policy{
+			{Name: "writes", Methods: []string{"POST"}, Rate: 1, Burst: 1},
+			{Name: "user-reads", Group: "users", Rate: 1, Burst: 3},
+		},
+	})
+
+	require.Equal(t, http.StatusOK, doRequest(t, router, "POST", "/posts", "user-1", "10.0.0.1").Code)
+	require.Equal(t, http.StatusTooManyRequests, doRequest(t, router, "POST", "/posts", "user-1", "10.0.0.1").Code)
+
+	// reads have their own bucket, so the exhausted write limit does not block them
+	w := doRequest(t, router, "GET", "/posts", "user-1", "10.0.0.1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "2", w.Header().Get(HeaderRateLimitLimit))
+
+	w = doRequest(t, router, "GET", "/users/1", "user-1", "10.0.0.1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "3", w.Header().Get(HeaderRateLimitLimit))
+}
+
+func TestRateLimitMiddleware_AnonymousPerIP(t *testing.T) {
+	router := newRateLimitRouter(ratelimit.Policies{
+		Default: ratelimit.Policy{Name: "default", Rate: 1, Burst: 5},
+		Rules: []ratelimit.Policy{
+			{Name: "anonymous", Tier: ratelimit.TierAnonymous, Rate: 1, Burst: 1},
+		},
+	})
+
+	require.Equal(t, http.StatusOK, doRequest(t, router, "GET", "/posts", "", "10.0.0.1").Code)
+	require.Equal(t, http.StatusTooManyRequests, doRequest(t, router, "GET", "/posts", "", "10.0.0.1").Code)
+	require.Equal(t, http.StatusOK, doRequest(t, router, "GET", "/posts", "", "10.0.0.2").Code)
+	require.Equal(t, http.StatusOK, doRequest(t, router, "GET", "/posts", "user-1", "10.0.0.1").Code)
+}
+
+func TestRateLimitMiddleware_ForwardedFor(t *testing.T) {
+	policies := ratelimit.Policies{
+		Default: ratelimit.Policy{Name: "default", Rate: 1, Burst: 5},
+		Rules: []ratelimit.Policy{
+			{Name: "anonymous", Tier: ratelimit.TierAnonymous, Rate: 1, Burst: 1},
+		},
+	}
+	forwardedFor := func(router *gin.Engine, forwarded string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// no proxy is trusted, so a forged header does not give the client a fresh bucket
+	router := newRateLimitRouter(policies)
+	require.Equal(t, http.StatusOK, forwardedFor(router, "192.0.2.1"))
+	require.Equal(t, http.StatusTooManyRequests, forwardedFor(router, "192.0.2.2"))
+
+	// behind a trusted proxy each forwarded client has its own bucket
+	cfg := &config.Config{RateLimitPolicies: policies, CORSAllowedOrigins: []string{"*"}, TrustedProxies: []string{"10.0.0.0/8"}}
+	router = newConfigRouter(config.NewStore(cfg, "", zap.NewNop()))
+	require.Equal(t, http.StatusOK, forwardedFor(router, "192.0.2.1"))
+	require.Equal(t, http.StatusOK, forwardedFor(router, "192.0.2.2"))
+	require.Equal(t, http.StatusTooManyRequests, forwardedFor(router, "192.0.2.2"))
+}
+
+func TestMiddlewares_ConfigReload(t *testing.T) {
+	t.Setenv(config.EnvRateLimitKey, "1")
+	t.Setenv(config.EnvCORSAllowedOrigins, "https://a.example")
+	cfg, err := config.Load(zap.NewNop(), "")
+	require.NoError(t, err)
+	store := config.NewStore(cfg, "", zap.NewNop())
+	router := newConfigRouter(store)
+
+	fromOrigin := func(origin string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("X-User", "user-1")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := fromOrigin("https://a.example")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "https://a.example", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "1", w.Header().Get(HeaderRateLimitLimit))
+
+	t.Setenv(config.EnvRateLimitKey, "5")
+	t.Setenv(config.EnvCORSAllowedOrigins, "https://b.example")
+	require.NoError(t, store.Reload("test"))
+
+	// the next request sees the new limit and origins without rebuilding the router
+	w = fromOrigin("https://b.example")
+	require.Equal(t, "https://b.example", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "5", w.Header().Get(HeaderRateLimitLimit))
+
+	w = fromOrigin("https://a.example")
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestRequireQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQuotaService := mocks.NewMockQuotaService(ctrl)
+	mws := New(zap.NewNop(), config.NewStore(&config.Config{}, "", zap.NewNop()), nil, nil, nil, nil, mockQuotaService)
+
+	router := gin.New()
+	router.POST("/posts", func(c *gin.Context) {
+		if key := c.GetHeader("X-Key-ID"); key != "" {
+			c.Set(APIKeyIDKey, key)
+		}
+	}, mws.RequireQuota(domain.QuotaPosts), func(c *gin.Context) {
+		if status, err := strconv.Atoi(c.Query("fail")); err == nil {
+			c.Status(status)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	do := func(keyID, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		// the client has gone away by the time the response is written
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, err := http.NewRequestWithContext(ctx, "POST", "/posts"+query, nil)
+		require.NoError(t, err)
+		if keyID != "" {
+			req.Header.Set("X-Key-ID", keyID)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	usage := &domain.QuotaUsage{Kind: domain.QuotaPosts, Period: "2025-01-31", Used: 1, Limit: 2, Remaining: 1, ResetsAt: time.Now().Add(time.Hour).Format(time.RFC3339)}
+
+	mockQuotaService.EXPECT().Consume(gomock.Any(), "key-1", domain.QuotaPosts).Return(usage, nil)
+	require.Equal(t, http.StatusOK, do("key-1", "").Code)
+
+	// client errors still count
+	mockQuotaService.EXPECT().Consume(gomock.Any(), "key-1", domain.QuotaPosts).Return(usage, nil)
+	require.Equal(t, http.StatusBadRequest, do("key-1", "?fail=400").Code)
+
+	// server errors are given back to the period they were counted in, even though the
+	// request was cancelled
+	mockQuotaService.EXPECT().Consume(gomock.Any(), "key-1", domain.QuotaPosts).Return(usage, nil)
+	mockQuotaService.EXPECT().Refund(gomock.Any(), "key-1", domain.QuotaPosts, "2025-01-31").
+		DoAndReturn(func(ctx context.Context, _ string, _ domain.QuotaKind, _ string) error {
+			require.NoError(t, ctx.Err())
+			_, ok := ctx.Deadline()
+			require.True(t, ok, "the refund should be bounded by a timeout")
+			return nil
+		})
+	require.Equal(t, http.StatusInternalServerError, do("key-1", "?fail=500").Code)
+
+	mockQuotaService.EXPECT().Consume(gomock.Any(), "key-1", domain.QuotaPosts).Return(usage, domain.ErrQuotaExceededFor(domain.QuotaPosts))
+	w := do("key-1", "")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Contains(t, w.Body.String(), "APP-429002")
+	retryAfter, err := strconv.Atoi(w.Header().Get(HeaderRetryAfter))
+	require.NoError(t, err)
+	require.InDelta(t, 3600, retryAfter, 5)
+
+	// requests without a key are not counted
+	require.Equal(t, http.StatusOK, do("", "").Code)
+}
+
+func TestTokenScopes(t *testing.T) {
+	// a token without a scope claim acts as a regular user
+	require.Equal(t, domain.UserScopes, tokenScopes(&jwtauth.Claims{Subject: "user-1"}))
+	// an empty claim grants nothing
+	require.Empty(t, tokenScopes(&jwtauth.Claims{Subject: "user-1", Scopes: []string{}, HasScope: true}))
+	// unknown scopes are dropped
+	require.Equal(t, domain.Scopes{domain.ScopePostsRead}, tokenScopes(&jwtauth.Claims{Subject: "user-1", Scopes: []string{"posts:read", "everything"}, HasScope: true}))
+}
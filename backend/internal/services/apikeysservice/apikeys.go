@@ -0,0 +1,210 @@
+package apikeysservice
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/pkg/apikey"
+)
+
+type service struct {
+	repo   apiKeysRepo
+	keyTTL time.Duration
+	logger *zap.Logger
+}
+
+// New creates the service, keyTTL is the lifetime of keys created without an explicit expiry
+func New(repo apiKeysRepo, keyTTL time.Duration, logger *zap.Logger) domain.APIKeyService {
+	logger = logger.With(zap.String("package", "apikeysservice"))
+
+	return &service{
+		repo:   repo,
+		keyTTL: keyTTL,
+		logger: logger,
+	}
+}
+
+//go:generate mockgen -destination=./mocks/mock_repo.go -package=mocks github.com/victor-nach/postr-backend/internal/services/apikeysservice apiKeysRepo
+type apiKeysRepo interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	Get(ctx context.Context, id string) (*domain.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	List(ctx context.Context) ([]domain.APIKey, error)
+	Revoke(ctx context.Context, id string, revokedAt string) error
+	Rotate(ctx context.Context, id string, replacement *domain.APIKey, retireAt string, graceful bool) error
+	RecordUsage(ctx context.Context, usage []domain.APIKeyUsage) error
+}
+
+// Create issues a new key, the plaintext key is only available on the returned value
+func (s *service) Create(ctx context.Context, params domain.CreateAPIKeyParams) (*domain.IssuedAPIKey, error) {
+	logr := s.logger.With(zap.String("method", "Create"))
+
+	expiresAt := time.Now().Add(s.keyTTL)
+	if params.ExpiresAt != nil {
+		expiresAt = *params.ExpiresAt
+	}
+
+	issued, err := newKey(params.OwnerID, params.Name, params.Scopes, expiresAt)
+	if err != nil {
+		logr.Error("Error generating API key", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	if err := s.repo.Create(ctx, &issued.APIKey); err != nil {
+		logr.Error("Error creating API key", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	logr.Info("API key created successfully", zap.String("id", issued.ID), zap.String("owner_id", issued.OwnerID), zap.String("prefix", issued.Prefix))
+	return issued, nil
+}
+
+func (s *service) List(ctx context.Context) ([]domain.APIKey, error) {
+	logr := s.logger.With(zap.String("method", "List"))
+
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		logr.Error("Error listing API keys", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	logr.Info("API keys listed successfully", zap.Int("count", len(keys)))
+	return keys, nil
+}
+
+func (s *service) Revoke(ctx context.Context, id string) error {
+	logr := s.logger.With(zap.String("method", "Revoke"))
+
+	if err := s.repo.Revoke(ctx, id, time.Now().Format(time.RFC3339)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("Active API key not found", zap.String("id", id))
+			return domain.ErrAPIKeyNotFound
+		}
+
+		logr.Error("Error revoking API key", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	logr.Info("API key revoked successfully", zap.String("id", id))
+	return nil
+}
+
+// Rotate issues a replacement for an active key with the same owner, name and scopes. Without a
+// grace period the old key is revoked straight away, with one it keeps working until the grace
+// period ends, or until it expires if that is sooner.
+func (s *service) Rotate(ctx context.Context, id string, grace time.Duration) (*domain.IssuedAPIKey, error) {
+	logr := s.logger.With(zap.String("method", "Rotate"))
+
+	current, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("API key not found", zap.String("id", id))
+			return nil, domain.ErrAPIKeyNotFound
+		}
+
+		logr.Error("Error retrieving API key", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	now := time.Now()
+	if current.RevokedAt != nil || current.Expired(now) {
+		logr.Info("API key already revoked or expired", zap.String("id", id))
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	issued, err := newKey(current.OwnerID, current.Name, current.Scopes, now.Add(s.keyTTL))
+	if err != nil {
+		logr.Error("Error generating API key", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	retireAt := now.Format(time.RFC3339)
+	if grace > 0 {
+		retireAt = now.Add(grace).Format(time.RFC3339)
+		if current.ExpiresAt != nil {
+			if expiresAt, err := time.Parse(time.RFC3339, *current.ExpiresAt); err == nil && expiresAt.Before(now.Add(grace)) {
+				retireAt = *current.ExpiresAt
+			}
+		}
+	}
+
+	if err := s.repo.Rotate(ctx, id, &issued.APIKey, retireAt, grace > 0); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("Active API key not found", zap.String("id", id))
+			return nil, domain.ErrAPIKeyNotFound
+		}
+
+		logr.Error("Error rotating API key", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	logr.Info("API key rotated successfully", zap.String("id", id), zap.String("replacement_id", issued.ID), zap.String("old_key_retired_at", retireAt))
+	return issued, nil
+}
+
+// Authenticate resolves a raw key to its stored record. Unknown, revoked and mismatched keys
+// all return domain.ErrInvalidAPIKey, expired keys return domain.ErrAPIKeyExpired.
+func (s *service) Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error) {
+	logr := s.logger.With(zap.String("method", "Authenticate"))
+
+	prefix, secret, err := apikey.Parse(rawKey)
+	if err != nil {
+		logr.Info("Malformed API key")
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("Unknown API key", zap.String("prefix", prefix))
+			return nil, domain.ErrInvalidAPIKey
+		}
+
+		logr.Error("Error retrieving API key", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	if !apikey.Verify(key.Salt, key.Hash, secret) {
+		logr.Info("API key secret mismatch", zap.String("prefix", prefix))
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if key.RevokedAt != nil {
+		logr.Info("Revoked API key", zap.String("prefix", prefix))
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if key.Expired(time.Now()) {
+		logr.Info("Expired API key", zap.String("prefix", prefix))
+		return nil, domain.ErrAPIKeyExpired
+	}
+
+	return key, nil
+}
+
+func newKey(ownerID, name string, scopes domain.Scopes, expiresAt time.Time) (*domain.IssuedAPIKey, error) {
+	generated, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+	expires := expiresAt.Format(time.RFC3339)
+
+	return &domain.IssuedAPIKey{
+		APIKey: domain.APIKey{
+			ID:        strings.ReplaceAll(uuid.New().String(), "-", ""),
+			OwnerID:   ownerID,
+			Name:      name,
+			Scopes:    scopes,
+			Prefix:    generated.Prefix,
+			Salt:      generated.Salt,
+			Hash:      generated.Hash,
+			CreatedAt: time.Now().Format(time.RFC3339),
+			ExpiresAt: &expires,
+		},
+		Key: generated.Raw,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package apikeysservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/internal/services/apikeysservice/mocks"
+	"github.com/victor-nach/postr-backend/pkg/apikey"
+)
+
+func TestService_CreateAndAuthenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockapiKeysRepo(ctrl)
+	svc := New(mockRepo, time.Hour, zap.NewNop())
+	ctx := context.Background()
+
+	var stored domain.APIKey
+	mockRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, key *domain.APIKey) error {
+		stored = *key
+		return nil
+	})
+
+	issued, err := svc.Create(ctx, domain.CreateAPIKeyParams{OwnerID: "owner-1", Name: "ci", Scopes: domain.Scopes{domain.ScopePostsRead}})
+	require.NoError(t, err)
+	require.Equal(t, domain.Scopes{domain.ScopePostsRead}, issued.Scopes)
+	require.NotContains(t, stored.Hash, issued.Key)
+	require.NotNil(t, stored.ExpiresAt)
+
+	_, secret, err := apikey.Parse(issued.Key)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, stored.Hash)
+
+	mockRepo.EXPECT().GetByPrefix(ctx, stored.Prefix).Return(&stored, nil)
+
+	key, err := svc.Authenticate(ctx, issued.Key)
+	require.NoError(t, err)
+	require.Equal(t, "owner-1", key.OwnerID)
+}
+
+func TestService_Authenticate_Rejects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockapiKeysRepo(ctrl)
+	svc := New(mockRepo, time.Hour, zap.NewNop())
+	ctx := context.Background()
+
+	generated, err := apikey.Generate()
+	require.NoError(t, err)
+	stored := domain.APIKey{ID: "key-1", OwnerID: "owner-1", Prefix: generated.Prefix, Salt: generated.Salt, Hash: generated.Hash}
+	revokedAt := "2025-01-01T00:00:00Z"
+	revoked := stored
+	revoked.RevokedAt = &revokedAt
+
+	// malformed keys never reach the repo
+	_, err = svc.Authenticate(ctx, "random_key")
+	require.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+
+	mockRepo.EXPECT().GetByPrefix(ctx, generated.Prefix).Return(nil, gorm.ErrRecordNotFound)
+	_, err = svc.Authenticate(ctx, generated.Raw)
+	require.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+
+	mockRepo.EXPECT().GetByPrefix(ctx, generated.Prefix).Return(&stored, nil)
+	_, err = svc.Authenticate(ctx, generated.Raw+"x")
+	require.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+
+	mockRepo.EXPECT().GetByPrefix(ctx, generated.Prefix).Return(&revoked, nil)
+	_, err = svc.Authenticate(ctx, generated.Raw)
+	require.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+
+	expiredAt := "2025-01-01T00:00:00Z"
+	expired := stored
+	expired.ExpiresAt = &expiredAt
+	mockRepo.EXPECT().GetByPrefix(ctx, generated.Prefix).Return(&expired, nil)
+	_, err = svc.Authenticate(ctx, generated.Raw)
+	require.ErrorIs(t, err, domain.ErrAPIKeyExpired)
+}
+
+func TestService_Rotate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockapiKeysRepo(ctrl)
+	svc := New(mockRepo, time.Hour, zap.NewNop())
+	ctx := context.Background()
+
+	current := &domain.APIKey{ID: "key-1", OwnerID: "owner-1", Name: "ci", Scopes: domain.Scopes{domain.ScopeAdmin}}
+	mockRepo.EXPECT().Get(ctx, "key-1").Return(current, nil)
+	mockRepo.EXPECT().Rotate(ctx, "key-1", gomock.Any(), gomock.Any(), false).Return(nil)
+
+	issued, err := svc.Rotate(ctx, "key-1", 0)
+	require.NoError(t, err)
+	require.NotEqual(t, current.ID, issued.ID)
+	require.Equal(t, current.OwnerID, issued.OwnerID)
+	require.Equal(t, current.Scopes, issued.Scopes)
+
+	mockRepo.EXPECT().Get(ctx, "missing").Return(nil, gorm.ErrRecordNotFound)
+	_, err = svc.Rotate(ctx, "missing", 0)
+	require.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+}
+
+func TestService_Rotate_GracePeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockapiKeysRepo(ctrl)
+	svc := New(mockRepo, time.Hour, zap.NewNop())
+	ctx := context.Background()
+
+	current := &domain.APIKey{ID: "key-1", OwnerID: "owner-1", Scopes: domain.Scopes{domain.ScopePostsRead}}
+	mockRepo.EXPECT().Get(ctx, "key-1").Return(current, nil)
+	mockRepo.EXPECT().Rotate(ctx, "key-1", gomock.Any(), gomock.Any(), true).
+		DoAndReturn(func(_ context.Context, _ string, _ *domain.APIKey, retireAt string, _ bool) error {
+			at, err := time.Parse(time.RFC3339, retireAt)
+			require.NoError(t, err)
+			require.WithinDuration(t, time.Now().Add(30*time.Minute), at, 5*time.Second)
+			return nil
+		})
+
+	_, err := svc.Rotate(ctx, "key-1", 30*time.Minute)
+	require.NoError(t, err)
+
+	// the grace period never extends the old key past its own expiry
+	expiresAt := time.Now().Add(10 * time.Minute).Format(time.RFC3339)
+	expiring := *current
+	expiring.ExpiresAt = &expiresAt
+	mockRepo.EXPECT().Get(ctx, "key-1").Return(&expiring, nil)
+	mockRepo.EXPECT().Rotate(ctx, "key-1", gomock.Any(), expiresAt, true).Return(nil)
+
+	_, err = svc.Rotate(ctx, "key-1", 30*time.Minute)
+	require.NoError(t, err)
+
+	expiredAt := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	expired := *current
+	expired.ExpiresAt = &expiredAt
+	mockRepo.EXPECT().Get(ctx, "key-1").Return(&expired, nil)
+
+	_, err = svc.Rotate(ctx, "key-1", 30*time.Minute)
+	require.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+}
@@ -0,0 +1,143 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/victor-nach/postr-backend/internal/services/apikeysservice (interfaces: apiKeysRepo)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_repo.go -package=mocks github.com/victor-nach/postr-backend/internal/services/apikeysservice apiKeysRepo
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/victor-nach/postr-backend/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockapiKeysRepo is a mock of apiKeysRepo interface.
+type MockapiKeysRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockapiKeysRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockapiKeysRepoMockRecorder is the mock recorder for MockapiKeysRepo.
+type MockapiKeysRepoMockRecorder struct {
+	mock *MockapiKeysRepo
+}
+
+// NewMockapiKeysRepo creates a new mock instance.
+func NewMockapiKeysRepo(ctrl *gomock.Controller) *MockapiKeysRepo {
+	mock := &MockapiKeysRepo{ctrl: ctrl}
+	mock.recorder = &MockapiKeysRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockapiKeysRepo) EXPECT() *MockapiKeysRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockapiKeysRepo) Create(ctx context.Context, key *domain.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockapiKeysRepoMockRecorder) Create(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockapiKeysRepo)(nil).Create), ctx, key)
+}
+
+// Get mocks base method.
+func (m *MockapiKeysRepo) Get(ctx context.Context, id string) (*domain.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*domain.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockapiKeysRepoMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockapiKeysRepo)(nil).Get), ctx, id)
+}
+
+// GetByPrefix mocks base method.
+func (m *MockapiKeysRepo) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByPrefix", ctx, prefix)
+	ret0, _ := ret[0].(*domain.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByPrefix indicates an expected call of GetByPrefix.
+func (mr *MockapiKeysRepoMockRecorder) GetByPrefix(ctx, prefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPrefix", reflect.TypeOf((*MockapiKeysRepo)(nil).GetByPrefix), ctx, prefix)
+}
+
+// List mocks base method.
+func (m *MockapiKeysRepo) List(ctx context.Context) ([]domain.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]domain.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockapiKeysRepoMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockapiKeysRepo)(nil).List), ctx)
+}
+
+// RecordUsage mocks base method.
+func (m *MockapiKeysRepo) RecordUsage(ctx context.Context, usage []domain.APIKeyUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordUsage", ctx, usage)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordUsage indicates an expected call of RecordUsage.
+func (mr *MockapiKeysRepoMockRecorder) RecordUsage(ctx, usage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordUsage", reflect.TypeOf((*MockapiKeysRepo)(nil).RecordUsage), ctx, usage)
+}
+
+// Revoke mocks base method.
+func (m *MockapiKeysRepo) Revoke(ctx context.Context, id, revokedAt string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockapiKeysRepoMockRecorder) Revoke(ctx, id, revokedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockapiKeysRepo)(nil).Revoke), ctx, id, revokedAt)
+}
+
+// Rotate mocks base method.
+func (m *MockapiKeysRepo) Rotate(ctx context.Context, id string, replacement *domain.APIKey, retireAt string, graceful bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", ctx, id, replacement, retireAt, graceful)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockapiKeysRepoMockRecorder) Rotate(ctx, id, replacement, retireAt, graceful any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockapiKeysRepo)(nil).Rotate), ctx, id, replacement, retireAt, graceful)
+}
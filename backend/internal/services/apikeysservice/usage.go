@@ -0,0 +1,97 @@
+package apikeysservice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+type usageRepo interface {
+	RecordUsage(ctx context.Context, usage []domain.APIKeyUsage) error
+}
+
+// UsageTracker records when and from where each key was last used. Record only updates a map in
+// memory, Run writes the latest use of each key in batches, so requests never wait on the write.
+type UsageTracker struct {
+	repo     usageRepo
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	pending map[string]domain.APIKeyUsage
+}
+
+func NewUsageTracker(repo usageRepo, interval time.Duration, logger *zap.Logger) *UsageTracker {
+	logger = logger.With(zap.String("package", "apikeysservice"))
+
+	return &UsageTracker{
+		repo:     repo,
+		interval: interval,
+		logger:   logger,
+		pending:  make(map[string]domain.APIKeyUsage),
+	}
+}
+
+// Record notes a use of the key, only the latest use of each key is kept until the next flush
+func (t *UsageTracker) Record(keyID, ip string) {
+	usage := domain.APIKeyUsage{KeyID: keyID, LastUsedAt: time.Now().Format(time.RFC3339), LastUsedIP: ip}
+
+	t.mu.Lock()
+	t.pending[keyID] = usage
+	t.mu.Unlock()
+}
+
+// Run flushes the recorded uses every interval until ctx is done, then flushes once more
+func (t *UsageTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.Flush(ctx)
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			t.Flush(flushCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+// Flush writes the recorded uses. Uses that fail to save are kept for the next flush unless
+// the key has been used again since.
+func (t *UsageTracker) Flush(ctx context.Context) {
+	logr := t.logger.With(zap.String("method", "Flush"))
+
+	t.mu.Lock()
+	batch := make([]domain.APIKeyUsage, 0, len(t.pending))
+	for _, usage := range t.pending {
+		batch = append(batch, usage)
+	}
+	t.pending = make(map[string]domain.APIKeyUsage)
+	t.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := t.repo.RecordUsage(ctx, batch); err != nil {
+		logr.Error("Error recording API key usage", zap.Error(err), zap.Int("count", len(batch)))
+
+		t.mu.Lock()
+		for _, usage := range batch {
+			if _, ok := t.pending[usage.KeyID]; !ok {
+				t.pending[usage.KeyID] = usage
+			}
+		}
+		t.mu.Unlock()
+		return
+	}
+
+	logr.Debug("API key usage recorded", zap.Int("count", len(batch)))
+}
@@ -0,0 +1,62 @@
+package apikeysservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/internal/services/apikeysservice/mocks"
+)
+
+func TestUsageTracker_Flush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockapiKeysRepo(ctrl)
+	tracker := NewUsageTracker(mockRepo, time.Minute, zap.NewNop())
+	ctx := context.Background()
+
+	// nothing recorded, nothing written
+	tracker.Flush(ctx)
+
+	tracker.Record("key-1", "10.0.0.1")
+	tracker.Record("key-1", "10.0.0.2")
+	tracker.Record("key-2", "10.0.0.3")
+
+	mockRepo.EXPECT().RecordUsage(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, usage []domain.APIKeyUsage) error {
+		require.Len(t, usage, 2)
+		for _, u := range usage {
+			if u.KeyID == "key-1" {
+				require.Equal(t, "10.0.0.2", u.LastUsedIP)
+			}
+		}
+		return nil
+	})
+	tracker.Flush(ctx)
+
+	// written usage is not written again
+	tracker.Flush(ctx)
+}
+
+func TestUsageTracker_FlushRetriesFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockapiKeysRepo(ctrl)
+	tracker := NewUsageTracker(mockRepo, time.Minute, zap.NewNop())
+	ctx := context.Background()
+
+	tracker.Record("key-1", "10.0.0.1")
+
+	mockRepo.EXPECT().RecordUsage(ctx, gomock.Any()).Return(errors.New("database is locked"))
+	tracker.Flush(ctx)
+
+	mockRepo.EXPECT().RecordUsage(ctx, gomock.Len(1)).Return(nil)
+	tracker.Flush(ctx)
+}
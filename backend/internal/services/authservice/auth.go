@@ -0,0 +1,334 @@
+package authservice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+// Options controls token lifetimes and the login lockout
+type Options struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// MaxFailedLogins in a row lock the account for LockoutDuration
+	MaxFailedLogins int
+	LockoutDuration time.Duration
+}
+
+type service struct {
+	authRepo  authRepo
+	usersRepo usersRepo
+	signer    tokenSigner
+	opts      Options
+	logger    *zap.Logger
+	// dummyHash is compared against when the user has no password, so unknown users take
+	// as long to reject as wrong passwords
+	dummyHash []byte
+}
+
+func New(authRepo authRepo, usersRepo usersRepo, signer tokenSigner, opts Options, logger *zap.Logger) domain.AuthService {
+	logger = logger.With(zap.String("package", "authservice"))
+
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("not-a-password"), bcrypt.DefaultCost)
+
+	return &service{
+		authRepo:  authRepo,
+		usersRepo: usersRepo,
+		signer:    signer,
+		opts:      opts,
+		logger:    logger,
+		dummyHash: dummyHash,
+	}
+}
+
+//go:generate mockgen -destination=./mocks/mock_authrepo.go -package=mocks github.com/victor-nach/postr-backend/internal/services/authservice authRepo
+type authRepo interface {
+	GetCredentials(ctx context.Context, userID string) (*domain.Credentials, error)
+	SaveCredentials(ctx context.Context, creds *domain.Credentials) error
+	RecordLoginFailure(ctx context.Context, userID string, maxAttempts int, lockedUntil string) (bool, error)
+	ResetLoginFailures(ctx context.Context, userID string) error
+	CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, id string, usedAt string, replacement *domain.RefreshToken) error
+	RevokeSession(ctx context.Context, sessionID string, revokedAt string) error
+	RevokeUserSessions(ctx context.Context, userID string, revokedAt string) error
+}
+
+//go:generate mockgen -destination=./mocks/mock_usersrepo.go -package=mocks github.com/victor-nach/postr-backend/internal/services/authservice usersRepo
+type usersRepo interface {
+	GetByUsername(ctx context.Context, username string) (*domain.User, error)
+	Validate(ctx context.Context, userID string) error
+}
+
+type tokenSigner interface {
+	Sign(subject string, ttl time.Duration) (string, error)
+}
+
+// SetPassword creates or replaces a user's password and signs the user out everywhere
+func (s *service) SetPassword(ctx context.Context, actor domain.Principal, userID, password string) error {
+	logr := s.logger.With(zap.String("method", "SetPassword"))
+
+	if !actor.CanModify(userID) {
+		logr.Warn("Principal cannot set another user's password", zap.String("principal", actor.UserID), zap.String("user_id", userID))
+		return domain.ErrPasswordForbidden
+	}
+
+	if err := s.usersRepo.Validate(ctx, userID); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			logr.Info("User not found", zap.String("user_id", userID))
+			return domain.ErrUserNotFound
+		}
+
+		logr.Error("Error validating user", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		logr.Error("Error hashing password", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	creds := &domain.Credentials{UserID: userID, PasswordHash: string(hash), UpdatedAt: now}
+	if err := s.authRepo.SaveCredentials(ctx, creds); err != nil {
+		logr.Error("Error saving credentials", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	if err := s.authRepo.RevokeUserSessions(ctx, userID, now); err != nil {
+		logr.Error("Error revoking sessions", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	logr.Info("Password set successfully", zap.String("user_id", userID))
+	return nil
+}
+
+// Login checks a username and password and starts a new session. Unknown users, users without
+// a password and wrong passwords all return domain.ErrInvalidCredentials.
+func (s *service) Login(ctx context.Context, username, password string) (*domain.AuthTokens, error) {
+	logr := s.logger.With(zap.String("method", "Login"))
+
+	user, err := s.usersRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+			logr.Info("Unknown username")
+			return nil, domain.ErrInvalidCredentials
+		}
+
+		logr.Error("Error retrieving user", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	creds, err := s.authRepo.GetCredentials(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+			logr.Info("User has no password", zap.String("user_id", user.ID))
+			return nil, domain.ErrInvalidCredentials
+		}
+
+		logr.Error("Error retrieving credentials", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	// the password is always compared and a locked account gets the same error as a wrong
+	// password, so neither the response nor its timing tells whether the account exists
+	mismatch := bcrypt.CompareHashAndPassword([]byte(creds.PasswordHash), []byte(password))
+
+	now := time.Now()
+	if locked(creds, now) {
+		logr.Info("Login attempt on locked account", zap.String("user_id", user.ID))
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if mismatch != nil {
+		lockedUntil := now.Add(s.opts.LockoutDuration).Format(time.RFC3339)
+		isLocked, err := s.authRepo.RecordLoginFailure(ctx, user.ID, s.opts.MaxFailedLogins, lockedUntil)
+		if err != nil {
+			logr.Error("Error recording failed login", zap.Error(err))
+			return nil, domain.ErrInternalServer
+		}
+		if isLocked {
+			logr.Warn("Account locked after failed logins", zap.String("user_id", user.ID), zap.String("locked_until", lockedUntil))
+			return nil, domain.ErrInvalidCredentials
+		}
+
+		logr.Info("Wrong password", zap.String("user_id", user.ID))
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if creds.FailedAttempts > 0 || creds.LockedUntil != nil {
+		if err := s.authRepo.ResetLoginFailures(ctx, user.ID); err != nil {
+			logr.Error("Error resetting failed logins", zap.Error(err))
+			return nil, domain.ErrInternalServer
+		}
+	}
+
+	refresh, raw, err := s.newRefreshToken(uuid.NewString(), user.ID, now)
+	if err != nil {
+		logr.Error("Error generating refresh token", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	if err := s.authRepo.CreateRefreshToken(ctx, refresh); err != nil {
+		logr.Error("Error creating refresh token", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	tokens, err := s.issue(user.ID, refresh, raw)
+	if err != nil {
+		logr.Error("Error signing access token", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	logr.Info("User logged in successfully", zap.String("user_id", user.ID), zap.String("session_id", refresh.SessionID))
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for new tokens. Each refresh token works once, presenting
+// one again revokes its whole session since it has most likely been stolen.
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*domain.AuthTokens, error) {
+	logr := s.logger.With(zap.String("method", "Refresh"))
+
+	current, err := s.authRepo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("Unknown refresh token")
+			return nil, domain.ErrInvalidRefreshToken
+		}
+
+		logr.Error("Error retrieving refresh token", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	now := time.Now()
+	if current.RevokedAt != nil {
+		logr.Info("Revoked refresh token", zap.String("session_id", current.SessionID))
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if current.UsedAt != nil {
+		return nil, s.revokeReusedSession(ctx, logr, current, now)
+	}
+	if expiresAt, err := time.Parse(time.RFC3339, current.ExpiresAt); err != nil || !now.Before(expiresAt) {
+		logr.Info("Expired refresh token", zap.String("session_id", current.SessionID))
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	replacement, raw, err := s.newRefreshToken(current.SessionID, current.UserID, now)
+	if err != nil {
+		logr.Error("Error generating refresh token", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	if err := s.authRepo.RotateRefreshToken(ctx, current.ID, now.Format(time.RFC3339), replacement); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// another request used the token first
+			return nil, s.revokeReusedSession(ctx, logr, current, now)
+		}
+
+		logr.Error("Error rotating refresh token", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	tokens, err := s.issue(current.UserID, replacement, raw)
+	if err != nil {
+		logr.Error("Error signing access token", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	logr.Info("Tokens refreshed successfully", zap.String("user_id", current.UserID), zap.String("session_id", current.SessionID))
+	return tokens, nil
+}
+
+// Logout revokes the session the refresh token belongs to. Access tokens already issued stay
+// valid until they expire.
+func (s *service) Logout(ctx context.Context, refreshToken string) error {
+	logr := s.logger.With(zap.String("method", "Logout"))
+
+	current, err := s.authRepo.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("Unknown refresh token")
+			return domain.ErrInvalidRefreshToken
+		}
+
+		logr.Error("Error retrieving refresh token", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	if err := s.authRepo.RevokeSession(ctx, current.SessionID, time.Now().Format(time.RFC3339)); err != nil {
+		logr.Error("Error revoking session", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	logr.Info("User logged out successfully", zap.String("user_id", current.UserID), zap.String("session_id", current.SessionID))
+	return nil
+}
+
+func (s *service) revokeReusedSession(ctx context.Context, logr *zap.Logger, token *domain.RefreshToken, now time.Time) error {
+	logr.Warn("Refresh token reused, revoking session", zap.String("user_id", token.UserID), zap.String("session_id", token.SessionID))
+
+	if err := s.authRepo.RevokeSession(ctx, token.SessionID, now.Format(time.RFC3339)); err != nil {
+		logr.Error("Error revoking session", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+	return domain.ErrInvalidRefreshToken
+}
+
+func (s *service) issue(userID string, refresh *domain.RefreshToken, rawRefresh string) (*domain.AuthTokens, error) {
+	access, err := s.signer.Sign(userID, s.opts.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AuthTokens{
+		AccessToken:      access,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.opts.AccessTokenTTL.Seconds()),
+		RefreshToken:     rawRefresh,
+		RefreshExpiresAt: refresh.ExpiresAt,
+	}, nil
+}
+
+func (s *service) newRefreshToken(sessionID, userID string, now time.Time) (*domain.RefreshToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	return &domain.RefreshToken{
+		ID:        uuid.NewString(),
+		SessionID: sessionID,
+		UserID:    userID,
+		TokenHash: hashToken(raw),
+		CreatedAt: now.Format(time.RFC3339),
+		ExpiresAt: now.Add(s.opts.RefreshTokenTTL).Format(time.RFC3339),
+	}, raw, nil
+}
+
+// hashToken hashes a refresh token for storage, the tokens are random so no salt is needed
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func locked(creds *domain.Credentials, now time.Time) bool {
+	if creds.LockedUntil == nil {
+		return false
+	}
+	lockedUntil, err := time.Parse(time.RFC3339, *creds.LockedUntil)
+	return err == nil && now.Before(lockedUntil)
+}
@@ -0,0 +1,189 @@
+package authservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/internal/services/authservice/mocks"
+	"github.com/victor-nach/postr-backend/pkg/jwtauth"
+)
+
+var testOptions = Options{
+	AccessTokenTTL:  time.Minute,
+	RefreshTokenTTL: time.Hour,
+	MaxFailedLogins: 3,
+	LockoutDuration: time.Minute,
+}
+
+func newTestService(t *testing.T) (domain.AuthService, *mocks.MockauthRepo, *mocks.MockusersRepo) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockAuthRepo := mocks.NewMockauthRepo(ctrl)
+	mockUsersRepo := mocks.NewMockusersRepo(ctrl)
+	svc := New(mockAuthRepo, mockUsersRepo, jwtauth.NewSigner("secret", "postr"), testOptions, zap.NewNop())
+	return svc, mockAuthRepo, mockUsersRepo
+}
+
+func testCredentials(t *testing.T, password string) *domain.Credentials {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	return &domain.Credentials{UserID: "user-1", PasswordHash: string(hash)}
+}
+
+func TestService_SetPassword(t *testing.T) {
+	svc, mockAuthRepo, mockUsersRepo := newTestService(t)
+	ctx := context.Background()
+	owner := domain.Principal{UserID: "user-1", Role: domain.RoleUser}
+
+	mockUsersRepo.EXPECT().Validate(ctx, "user-1").Return(nil)
+	mockAuthRepo.EXPECT().SaveCredentials(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, creds *domain.Credentials) error {
+		require.Equal(t, "user-1", creds.UserID)
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(creds.PasswordHash), []byte("correct horse")))
+		return nil
+	})
+	mockAuthRepo.EXPECT().RevokeUserSessions(ctx, "user-1", gomock.Any()).Return(nil)
+
+	require.NoError(t, svc.SetPassword(ctx, owner, "user-1", "correct horse"))
+
+	err := svc.SetPassword(ctx, owner, "user-2", "correct horse")
+	require.ErrorIs(t, err, domain.ErrPasswordForbidden)
+
+	mockUsersRepo.EXPECT().Validate(ctx, "missing").Return(domain.ErrUserNotFound)
+	err = svc.SetPassword(ctx, domain.Principal{UserID: "admin", Role: domain.RoleAdmin}, "missing", "correct horse")
+	require.ErrorIs(t, err, domain.ErrUserNotFound)
+}
+
+func TestService_Login(t *testing.T) {
+	svc, mockAuthRepo, mockUsersRepo := newTestService(t)
+	ctx := context.Background()
+
+	mockUsersRepo.EXPECT().GetByUsername(ctx, "bret").Return(&domain.User{ID: "user-1"}, nil)
+	mockAuthRepo.EXPECT().GetCredentials(ctx, "user-1").Return(testCredentials(t, "correct horse"), nil)
+	mockAuthRepo.EXPECT().CreateRefreshToken(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, token *domain.RefreshToken) error {
+		require.Equal(t, "user-1", token.UserID)
+		require.NotEmpty(t, token.SessionID)
+		return nil
+	})
+
+	tokens, err := svc.Login(ctx, "bret", "correct horse")
+	require.NoError(t, err)
+	require.Equal(t, "Bearer", tokens.TokenType)
+	require.Equal(t, 60, tokens.ExpiresIn)
+	require.NotEmpty(t, tokens.RefreshToken)
+
+	verifier, err := jwtauth.New(jwtauth.Config{HMACSecret: "secret", Audience: "postr"})
+	require.NoError(t, err)
+	claims, err := verifier.Verify(tokens.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+
+	mockUsersRepo.EXPECT().GetByUsername(ctx, "nobody").Return(nil, gorm.ErrRecordNotFound)
+	_, err = svc.Login(ctx, "nobody", "correct horse")
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
+}
+
+func TestService_Login_Lockout(t *testing.T) {
+	svc, mockAuthRepo, mockUsersRepo := newTestService(t)
+	ctx := context.Background()
+	creds := testCredentials(t, "correct horse")
+
+	mockUsersRepo.EXPECT().GetByUsername(ctx, "bret").Return(&domain.User{ID: "user-1"}, nil).Times(3)
+	mockAuthRepo.EXPECT().GetCredentials(ctx, "user-1").Return(creds, nil).Times(2)
+
+	mockAuthRepo.EXPECT().RecordLoginFailure(ctx, "user-1", 3, gomock.Any()).Return(false, nil)
+	_, err := svc.Login(ctx, "bret", "wrong")
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
+
+	mockAuthRepo.EXPECT().RecordLoginFailure(ctx, "user-1", 3, gomock.Any()).Return(true, nil)
+	_, err = svc.Login(ctx, "bret", "wrong")
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
+
+	// while the account is locked the right password does not get through, and the response is
+	// the one an unknown user gets, so it does not reveal that the account exists
+	lockedUntil := time.Now().Add(time.Minute).Format(time.RFC3339)
+	locked := *creds
+	locked.LockedUntil = &lockedUntil
+	mockUsersRepo.EXPECT().GetByUsername(ctx, "bret").Return(&domain.User{ID: "user-1"}, nil)
+	mockAuthRepo.EXPECT().GetCredentials(ctx, "user-1").Return(&locked, nil).Times(2)
+	_, err = svc.Login(ctx, "bret", "correct horse")
+	require.Equal(t, domain.ErrInvalidCredentials, err)
+	_, err = svc.Login(ctx, "bret", "wrong")
+	require.Equal(t, domain.ErrInvalidCredentials, err)
+
+	mockUsersRepo.EXPECT().GetByUsername(ctx, "nobody").Return(nil, gorm.ErrRecordNotFound)
+	_, err = svc.Login(ctx, "nobody", "wrong")
+	require.Equal(t, domain.ErrInvalidCredentials, err)
+}
+
+func TestService_Refresh(t *testing.T) {
+	svc, mockAuthRepo, _ := newTestService(t)
+	ctx := context.Background()
+
+	current := &domain.RefreshToken{
+		ID:        "token-1",
+		SessionID: "session-1",
+		UserID:    "user-1",
+		ExpiresAt: time.Now().Add(time.Hour).Format(time.RFC3339),
+	}
+	mockAuthRepo.EXPECT().GetRefreshToken(ctx, hashToken("raw-1")).Return(current, nil)
+	mockAuthRepo.EXPECT().RotateRefreshToken(ctx, "token-1", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ string, replacement *domain.RefreshToken) error {
+			require.Equal(t, "session-1", replacement.SessionID)
+			return nil
+		})
+
+	tokens, err := svc.Refresh(ctx, "raw-1")
+	require.NoError(t, err)
+	require.NotEqual(t, "raw-1", tokens.RefreshToken)
+
+	expired := *current
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Format(time.RFC3339)
+	mockAuthRepo.EXPECT().GetRefreshToken(ctx, hashToken("expired")).Return(&expired, nil)
+	_, err = svc.Refresh(ctx, "expired")
+	require.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+
+	mockAuthRepo.EXPECT().GetRefreshToken(ctx, hashToken("unknown")).Return(nil, gorm.ErrRecordNotFound)
+	_, err = svc.Refresh(ctx, "unknown")
+	require.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+}
+
+func TestService_Refresh_ReuseRevokesSession(t *testing.T) {
+	svc, mockAuthRepo, _ := newTestService(t)
+	ctx := context.Background()
+
+	usedAt := time.Now().Format(time.RFC3339)
+	used := &domain.RefreshToken{
+		ID:        "token-1",
+		SessionID: "session-1",
+		UserID:    "user-1",
+		ExpiresAt: time.Now().Add(time.Hour).Format(time.RFC3339),
+		UsedAt:    &usedAt,
+	}
+	mockAuthRepo.EXPECT().GetRefreshToken(ctx, hashToken("raw-1")).Return(used, nil)
+	mockAuthRepo.EXPECT().RevokeSession(ctx, "session-1", gomock.Any()).Return(nil)
+
+	_, err := svc.Refresh(ctx, "raw-1")
+	require.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+}
+
+func TestService_Logout(t *testing.T) {
+	svc, mockAuthRepo, _ := newTestService(t)
+	ctx := context.Background()
+
+	current := &domain.RefreshToken{ID: "token-1", SessionID: "session-1", UserID: "user-1"}
+	mockAuthRepo.EXPECT().GetRefreshToken(ctx, hashToken("raw-1")).Return(current, nil)
+	mockAuthRepo.EXPECT().RevokeSession(ctx, "session-1", gomock.Any()).Return(nil)
+	require.NoError(t, svc.Logout(ctx, "raw-1"))
+
+	mockAuthRepo.EXPECT().GetRefreshToken(ctx, hashToken("unknown")).Return(nil, gorm.ErrRecordNotFound)
+	require.ErrorIs(t, svc.Logout(ctx, "unknown"), domain.ErrInvalidRefreshToken)
+}
@@ -0,0 +1,171 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/victor-nach/postr-backend/internal/services/authservice (interfaces: authRepo)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_authrepo.go -package=mocks github.com/victor-nach/postr-backend/internal/services/authservice authRepo
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/victor-nach/postr-backend/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockauthRepo is a mock of authRepo interface.
+type MockauthRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockauthRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockauthRepoMockRecorder is the mock recorder for MockauthRepo.
+type MockauthRepoMockRecorder struct {
+	mock *MockauthRepo
+}
+
+// NewMockauthRepo creates a new mock instance.
+func NewMockauthRepo(ctrl *gomock.Controller) *MockauthRepo {
+	mock := &MockauthRepo{ctrl: ctrl}
+	mock.recorder = &MockauthRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockauthRepo) EXPECT() *MockauthRepoMockRecorder {
+	return m.recorder
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockauthRepo) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockauthRepoMockRecorder) CreateRefreshToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockauthRepo)(nil).CreateRefreshToken), ctx, token)
+}
+
+// GetCredentials mocks base method.
+func (m *MockauthRepo) GetCredentials(ctx context.Context, userID string) (*domain.Credentials, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCredentials", ctx, userID)
+	ret0, _ := ret[0].(*domain.Credentials)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCredentials indicates an expected call of GetCredentials.
+func (mr *MockauthRepoMockRecorder) GetCredentials(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCredentials", reflect.TypeOf((*MockauthRepo)(nil).GetCredentials), ctx, userID)
+}
+
+// GetRefreshToken mocks base method.
+func (m *MockauthRepo) GetRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshToken", ctx, tokenHash)
+	ret0, _ := ret[0].(*domain.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshToken indicates an expected call of GetRefreshToken.
+func (mr *MockauthRepoMockRecorder) GetRefreshToken(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshToken", reflect.TypeOf((*MockauthRepo)(nil).GetRefreshToken), ctx, tokenHash)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockauthRepo) RecordLoginFailure(ctx context.Context, userID string, maxAttempts int, lockedUntil string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, userID, maxAttempts, lockedUntil)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockauthRepoMockRecorder) RecordLoginFailure(ctx, userID, maxAttempts, lockedUntil any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockauthRepo)(nil).RecordLoginFailure), ctx, userID, maxAttempts, lockedUntil)
+}
+
+// ResetLoginFailures mocks base method.
+func (m *MockauthRepo) ResetLoginFailures(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginFailures", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginFailures indicates an expected call of ResetLoginFailures.
+func (mr *MockauthRepoMockRecorder) ResetLoginFailures(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginFailures", reflect.TypeOf((*MockauthRepo)(nil).ResetLoginFailures), ctx, userID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockauthRepo) RevokeSession(ctx context.Context, sessionID, revokedAt string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, sessionID, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockauthRepoMockRecorder) RevokeSession(ctx, sessionID, revokedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockauthRepo)(nil).RevokeSession), ctx, sessionID, revokedAt)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockauthRepo) RevokeUserSessions(ctx context.Context, userID, revokedAt string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", ctx, userID, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockauthRepoMockRecorder) RevokeUserSessions(ctx, userID, revokedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockauthRepo)(nil).RevokeUserSessions), ctx, userID, revokedAt)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockauthRepo) RotateRefreshToken(ctx context.Context, id, usedAt string, replacement *domain.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, id, usedAt, replacement)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockauthRepoMockRecorder) RotateRefreshToken(ctx, id, usedAt, replacement any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockauthRepo)(nil).RotateRefreshToken), ctx, id, usedAt, replacement)
+}
+
+// SaveCredentials mocks base method.
+func (m *MockauthRepo) SaveCredentials(ctx context.Context, creds *domain.Credentials) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCredentials", ctx, creds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCredentials indicates an expected call of SaveCredentials.
+func (mr *MockauthRepoMockRecorder) SaveCredentials(ctx, creds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCredentials", reflect.TypeOf((*MockauthRepo)(nil).SaveCredentials), ctx, creds)
+}
@@ -0,0 +1,53 @@
+package postsservice
+
+import (
+	"strings"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+// diffLines computes a line based diff turning a into b, using the longest common subsequence
+func diffLines(a, b string) []domain.DiffLine {
+	from := strings.Split(a, "\n")
+	to := strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]domain.DiffLine, 0, max(len(from), len(to)))
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			diff = append(diff, domain.DiffLine{Op: domain.DiffEqual, Text: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, domain.DiffLine{Op: domain.DiffDelete, Text: from[i]})
+			i++
+		default:
+			diff = append(diff, domain.DiffLine{Op: domain.DiffInsert, Text: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffDelete, Text: from[i]})
+	}
+	for ; j < len(to); j++ {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffInsert, Text: to[j]})
+	}
+
+	return diff
+}
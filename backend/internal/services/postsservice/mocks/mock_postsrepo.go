@@ -69,17 +69,107 @@ func (mr *MockpostsRepoMockRecorder) Delete(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockpostsRepo)(nil).Delete), ctx, id)
 }
 
+// Feed mocks base method.
+func (m *MockpostsRepo) Feed(ctx context.Context, params domain.FeedParams) (domain.PaginatedFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Feed", ctx, params)
+	ret0, _ := ret[0].(domain.PaginatedFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Feed indicates an expected call of Feed.
+func (mr *MockpostsRepoMockRecorder) Feed(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Feed", reflect.TypeOf((*MockpostsRepo)(nil).Feed), ctx, params)
+}
+
+// Get mocks base method.
+func (m *MockpostsRepo) Get(ctx context.Context, id string) (*domain.PostDetail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*domain.PostDetail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockpostsRepoMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockpostsRepo)(nil).Get), ctx, id)
+}
+
 // ListByUserID mocks base method.
-func (m *MockpostsRepo) ListByUserID(ctx context.Context, userId string) ([]domain.Post, error) {
+func (m *MockpostsRepo) ListByUserID(ctx context.Context, params domain.ListPostsParams) (domain.PaginatedPosts, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListByUserID", ctx, userId)
-	ret0, _ := ret[0].([]domain.Post)
+	ret := m.ctrl.Call(m, "ListByUserID", ctx, params)
+	ret0, _ := ret[0].(domain.PaginatedPosts)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListByUserID indicates an expected call of ListByUserID.
-func (mr *MockpostsRepoMockRecorder) ListByUserID(ctx, userId any) *gomock.Call {
+func (mr *MockpostsRepoMockRecorder) ListByUserID(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUserID", reflect.TypeOf((*MockpostsRepo)(nil).ListByUserID), ctx, params)
+}
+
+// ListByUserIDCursor mocks base method.
+func (m *MockpostsRepo) ListByUserIDCursor(ctx context.Context, userID string, params domain.CursorParams) (domain.PaginatedPosts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUserIDCursor", ctx, userID, params)
+	ret0, _ := ret[0].(domain.PaginatedPosts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUserIDCursor indicates an expected call of ListByUserIDCursor.
+func (mr *MockpostsRepoMockRecorder) ListByUserIDCursor(ctx, userID, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUserIDCursor", reflect.TypeOf((*MockpostsRepo)(nil).ListByUserIDCursor), ctx, userID, params)
+}
+
+// ListRevisions mocks base method.
+func (m *MockpostsRepo) ListRevisions(ctx context.Context, postID string) ([]domain.PostRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", ctx, postID)
+	ret0, _ := ret[0].([]domain.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockpostsRepoMockRecorder) ListRevisions(ctx, postID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockpostsRepo)(nil).ListRevisions), ctx, postID)
+}
+
+// Search mocks base method.
+func (m *MockpostsRepo) Search(ctx context.Context, params domain.SearchPostsParams) (domain.PaginatedSearchResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, params)
+	ret0, _ := ret[0].(domain.PaginatedSearchResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockpostsRepoMockRecorder) Search(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockpostsRepo)(nil).Search), ctx, params)
+}
+
+// Update mocks base method.
+func (m *MockpostsRepo) Update(ctx context.Context, id string, update domain.PostUpdate) (*domain.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, update)
+	ret0, _ := ret[0].(*domain.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockpostsRepoMockRecorder) Update(ctx, id, update any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUserID", reflect.TypeOf((*MockpostsRepo)(nil).ListByUserID), ctx, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockpostsRepo)(nil).Update), ctx, id, update)
 }
@@ -31,7 +31,13 @@ func New(postsRepo postsRepo, usersRepo usersRepo, logger *zap.Logger) domain.Po
 
 type postsRepo interface {
 	Create(ctx context.Context, post *domain.Post) error
-	ListByUserID(ctx context.Context, userId string) ([]domain.Post, error)
+	Get(ctx context.Context, id string) (*domain.PostDetail, error)
+	ListByUserID(ctx context.Context, params domain.ListPostsParams) (domain.PaginatedPosts, error)
+	ListByUserIDCursor(ctx context.Context, userID string, params domain.CursorParams) (domain.PaginatedPosts, error)
+	Feed(ctx context.Context, params domain.FeedParams) (domain.PaginatedFeed, error)
+	Search(ctx context.Context, params domain.SearchPostsParams) (domain.PaginatedSearchResults, error)
+	Update(ctx context.Context, id string, update domain.PostUpdate) (*domain.Post, error)
+	ListRevisions(ctx context.Context, postID string) ([]domain.PostRevision, error)
 	Delete(ctx context.Context, id string) error
 }
 
@@ -41,9 +47,15 @@ type usersRepo interface {
 	Validate(ctx context.Context, userID string) error
 }
 
-func (h *service) Create(ctx context.Context, post *domain.Post) error {
+// Create adds a post owned by post.UserID, only admins can post on behalf of another user
+func (h *service) Create(ctx context.Context, actor domain.Principal, post *domain.Post) error {
 	logr := h.logger.With(zap.String("method", "Create"))
 
+	if !actor.CanModify(post.UserID) {
+		logr.Warn("Principal cannot post as another user", zap.String("principal", actor.UserID), zap.String("user_id", post.UserID))
+		return domain.ErrPostForbidden
+	}
+
 	if err := h.validateUserID(ctx, post.UserID); err != nil {
 		logr.Error("Invalid userID", zap.Error(err))
 		return domain.ErrUserNotFound
@@ -59,31 +71,159 @@ func (h *service) Create(ctx context.Context, post *domain.Post) error {
 	return nil
 }
 
-func (h *service) List(ctx context.Context, userID string) ([]domain.Post, error) {
+func (h *service) Get(ctx context.Context, id string) (*domain.PostDetail, error) {
+	logr := h.logger.With(zap.String("method", "Get"))
+
+	post, err := h.postsRepo.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("Post not found", zap.String("id", id))
+			return nil, domain.ErrPostNotFound
+		}
+
+		logr.Error("Error retrieving post", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	logr.Info("Post retrieved successfully", zap.String("id", id))
+	return post, nil
+}
+
+func (h *service) List(ctx context.Context, params domain.ListPostsParams) (domain.PaginatedPosts, error) {
 	logr := h.logger.With(zap.String("method", "List"))
-	
-	if err := h.validateUserID(ctx, userID); err != nil {
+
+	if err := h.validateUserID(ctx, params.UserID); err != nil {
 		logr.Error("Invalid userID", zap.Error(err))
-		return []domain.Post{}, err
+		return domain.PaginatedPosts{}, err
 	}
 
-	posts, err := h.postsRepo.ListByUserID(ctx, userID)
+	paginatedPosts, err := h.postsRepo.ListByUserID(ctx, params)
 	if err != nil {
 		logr.Error("Error listing posts", zap.Error(err))
-		return []domain.Post{}, domain.ErrInternalServer
+		return domain.PaginatedPosts{}, domain.ErrInternalServer
+	}
+
+	logr.Info("Posts listed successfully", zap.String("user_id", params.UserID), zap.Int("count", len(paginatedPosts.Posts)))
+	return paginatedPosts, nil
+}
+
+func (h *service) ListByCursor(ctx context.Context, userID string, params domain.CursorParams) (domain.PaginatedPosts, error) {
+	logr := h.logger.With(zap.String("method", "ListByCursor"))
+
+	if err := h.validateUserID(ctx, userID); err != nil {
+		logr.Error("Invalid userID", zap.Error(err))
+		return domain.PaginatedPosts{}, err
+	}
+
+	paginatedPosts, err := h.postsRepo.ListByUserIDCursor(ctx, userID, params)
+	if err != nil {
+		logr.Error("Error listing posts by cursor", zap.Error(err))
+		return domain.PaginatedPosts{}, domain.ErrInternalServer
+	}
+
+	logr.Info("Posts listed successfully", zap.String("user_id", userID), zap.Int("count", len(paginatedPosts.Posts)))
+	return paginatedPosts, nil
+}
+
+// Feed returns the newest posts across all users along with their authors
+func (h *service) Feed(ctx context.Context, params domain.FeedParams) (domain.PaginatedFeed, error) {
+	logr := h.logger.With(zap.String("method", "Feed"))
+
+	feed, err := h.postsRepo.Feed(ctx, params)
+	if err != nil {
+		logr.Error("Error listing feed", zap.Error(err))
+		return domain.PaginatedFeed{}, domain.ErrInternalServer
+	}
+
+	logr.Info("Feed listed successfully", zap.Int("count", len(feed.Posts)))
+	return feed, nil
+}
+
+// Search returns the posts matching the query, best match first
+func (h *service) Search(ctx context.Context, params domain.SearchPostsParams) (domain.PaginatedSearchResults, error) {
+	logr := h.logger.With(zap.String("method", "Search"))
+
+	results, err := h.postsRepo.Search(ctx, params)
+	if err != nil {
+		logr.Error("Error searching posts", zap.Error(err))
+		return domain.PaginatedSearchResults{}, domain.ErrInternalServer
+	}
+
+	logr.Info("Posts searched successfully", zap.String("query", params.Query), zap.Int("count", len(results.Posts)))
+	return results, nil
+}
+
+// Update edits a post, only its author or an admin may do so
+func (h *service) Update(ctx context.Context, actor domain.Principal, id string, update domain.PostUpdate) (*domain.Post, error) {
+	logr := h.logger.With(zap.String("method", "Update"))
+
+	if err := h.authorize(ctx, logr, actor, id); err != nil {
+		return nil, err
+	}
+
+	post, err := h.postsRepo.Update(ctx, id, update)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("Post not found", zap.String("id", id))
+			return nil, domain.ErrPostNotFound
+		}
+
+		logr.Error("Error updating post", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	logr.Info("Post updated successfully", zap.Any("post", post))
+	return post, nil
+}
+
+// ListRevisions returns the previous versions of a post, oldest first, each with the
+// changes made by the version that replaced it
+func (h *service) ListRevisions(ctx context.Context, id string) ([]domain.PostRevisionDetail, error) {
+	logr := h.logger.With(zap.String("method", "ListRevisions"))
+
+	current, err := h.Get(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	logr.Info("Posts listed successfully", zap.String("user_id", userID), zap.Int("count", len(posts)))
-	return posts, nil
+	revisions, err := h.postsRepo.ListRevisions(ctx, id)
+	if err != nil {
+		logr.Error("Error listing post revisions", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	details := make([]domain.PostRevisionDetail, 0, len(revisions))
+	for i, revision := range revisions {
+		nextTitle, nextBody := current.Title, current.Body
+		if i+1 < len(revisions) {
+			nextTitle, nextBody = revisions[i+1].Title, revisions[i+1].Body
+		}
+
+		details = append(details, domain.PostRevisionDetail{
+			PostRevision: revision,
+			Changes: domain.PostChanges{
+				Title: diffLines(revision.Title, nextTitle),
+				Body:  diffLines(revision.Body, nextBody),
+			},
+		})
+	}
+
+	logr.Info("Post revisions listed successfully", zap.String("id", id), zap.Int("count", len(details)))
+	return details, nil
 }
 
-func (h *service) Delete(ctx context.Context, id string) error {
+// Delete removes a post, only its author or an admin may do so
+func (h *service) Delete(ctx context.Context, actor domain.Principal, id string) error {
 	logr := h.logger.With(zap.String("method", "Delete"))
 
+	if err := h.authorize(ctx, logr, actor, id); err != nil {
+		return err
+	}
+
 	if err := h.postsRepo.Delete(ctx, id); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logr.Info("Post not found", zap.String("id", id))
-			return domain.ErrUserNotFound
+			return domain.ErrPostNotFound
 		}
 
 		logr.Error("Error deleting post", zap.Error(err))
@@ -95,6 +235,21 @@ func (h *service) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// authorize checks that the post exists and the principal may modify it
+func (h *service) authorize(ctx context.Context, logr *zap.Logger, actor domain.Principal, id string) error {
+	post, err := h.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !actor.CanModify(post.UserID) {
+		logr.Warn("Principal does not own post", zap.String("principal", actor.UserID), zap.String("id", id))
+		return domain.ErrPostForbidden
+	}
+
+	return nil
+}
+
 func (h *service) validateUserID(ctx context.Context, userID string) error{
 	if err := h.usersRepo.Validate(ctx, userID); err != nil {
 		return domain.ErrUserNotFound
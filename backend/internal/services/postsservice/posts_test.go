@@ -2,6 +2,7 @@ package postsservice_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -38,7 +39,34 @@ func TestService_Create(t *testing.T) {
 	mockUsersRepo.EXPECT().Validate(ctx, post.UserID).Return(nil)
 	mockPostsRepo.EXPECT().Create(ctx, post).Return(nil)
 
-	err := svc.Create(ctx, post)
+	err := svc.Create(ctx, domain.Principal{UserID: post.UserID, Role: domain.RoleUser}, post)
+	require.NoError(t, err)
+}
+
+func TestService_Create_OnBehalfOfAnotherUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostsRepo := mocks.NewMockpostsRepo(ctrl)
+	mockUsersRepo := mocks.NewMockusersRepo(ctrl)
+
+	logger := zap.NewNop()
+	svc := postsservice.New(mockPostsRepo, mockUsersRepo, logger)
+
+	ctx := context.Background()
+	post := &domain.Post{
+		ID:     uuid.NewString(),
+		UserID: uuid.NewString(),
+		Title:  "Title 1",
+	}
+
+	err := svc.Create(ctx, domain.Principal{UserID: uuid.NewString(), Role: domain.RoleUser}, post)
+	require.ErrorIs(t, err, domain.ErrPostForbidden)
+
+	mockUsersRepo.EXPECT().Validate(ctx, post.UserID).Return(nil)
+	mockPostsRepo.EXPECT().Create(ctx, post).Return(nil)
+
+	err = svc.Create(ctx, domain.Principal{UserID: uuid.NewString(), Role: domain.RoleAdmin}, post)
 	require.NoError(t, err)
 }
 
@@ -70,12 +98,83 @@ func TestService_List_Success(t *testing.T) {
 		},
 	}
 
+	params := domain.ListPostsParams{UserID: userID, PageNumber: 1, PageSize: 10, Sort: domain.PostSortCreatedAt, Order: domain.SortDesc}
+	expectedPaginated := domain.PaginatedPosts{
+		Pagination: domain.Pagination{CurrentPage: 1, TotalPages: 1, TotalSize: 2},
+		Posts:      expectedPosts,
+	}
+
 	mockUsersRepo.EXPECT().Validate(ctx, userID).Return(nil)
-	mockPostsRepo.EXPECT().ListByUserID(ctx, userID).Return(expectedPosts, nil)
+	mockPostsRepo.EXPECT().ListByUserID(ctx, params).Return(expectedPaginated, nil)
 
-	posts, err := svc.List(ctx, userID)
+	posts, err := svc.List(ctx, params)
 	require.NoError(t, err)
-	require.Equal(t, expectedPosts, posts)
+	require.Equal(t, expectedPaginated, posts)
+}
+
+func TestService_Feed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostsRepo := mocks.NewMockpostsRepo(ctrl)
+	mockUsersRepo := mocks.NewMockusersRepo(ctrl)
+
+	logger := zap.NewNop()
+	svc := postsservice.New(mockPostsRepo, mockUsersRepo, logger)
+
+	ctx := context.Background()
+	params := domain.FeedParams{Since: "2025-01-01T00:00:00Z", PageNumber: 1, PageSize: 10}
+
+	expectedFeed := domain.PaginatedFeed{
+		Pagination: domain.Pagination{CurrentPage: 1, TotalPages: 1, TotalSize: 1},
+		Posts: []domain.PostDetail{
+			{
+				Post:   domain.Post{ID: uuid.NewString(), Title: "Post 1"},
+				Author: domain.Author{ID: uuid.NewString(), Username: "author"},
+			},
+		},
+	}
+
+	mockPostsRepo.EXPECT().Feed(ctx, params).Return(expectedFeed, nil)
+
+	feed, err := svc.Feed(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, expectedFeed, feed)
+
+	mockPostsRepo.EXPECT().Feed(ctx, params).Return(domain.PaginatedFeed{}, errors.New("db error"))
+
+	_, err = svc.Feed(ctx, params)
+	require.ErrorIs(t, err, domain.ErrInternalServer)
+}
+
+func TestService_Search(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostsRepo := mocks.NewMockpostsRepo(ctrl)
+	mockUsersRepo := mocks.NewMockusersRepo(ctrl)
+
+	logger := zap.NewNop()
+	svc := postsservice.New(mockPostsRepo, mockUsersRepo, logger)
+
+	ctx := context.Background()
+	params := domain.SearchPostsParams{Query: "zebra", PageNumber: 1, PageSize: 10}
+
+	expected := domain.PaginatedSearchResults{
+		Pagination: domain.Pagination{CurrentPage: 1, TotalPages: 1, TotalSize: 1},
+		Posts: []domain.PostSearchResult{
+			{
+				PostDetail: domain.PostDetail{Post: domain.Post{ID: uuid.NewString(), Title: "Zebra"}},
+				Snippet:    "<mark>Zebra</mark>",
+			},
+		},
+	}
+
+	mockPostsRepo.EXPECT().Search(ctx, params).Return(expected, nil)
+
+	results, err := svc.Search(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, expected, results)
 }
 
 func TestService_List_InvalidUser(t *testing.T) {
@@ -93,10 +192,10 @@ func TestService_List_InvalidUser(t *testing.T) {
 
 	mockUsersRepo.EXPECT().Validate(ctx, userID).Return(domain.ErrUserNotFound)
 
-	posts, err := svc.List(ctx, userID)
+	posts, err := svc.List(ctx, domain.ListPostsParams{UserID: userID, PageNumber: 1, PageSize: 10})
 	require.Error(t, err)
 	require.Equal(t, domain.ErrUserNotFound, err)
-	require.Empty(t, posts)
+	require.Empty(t, posts.Posts)
 }
 
 func TestService_Delete_Success(t *testing.T) {
@@ -111,13 +210,34 @@ func TestService_Delete_Success(t *testing.T) {
 
 	ctx := context.Background()
 	postID := uuid.NewString()
+	owner := domain.Principal{UserID: uuid.NewString(), Role: domain.RoleUser}
 
+	mockPostsRepo.EXPECT().Get(ctx, postID).Return(&domain.PostDetail{Post: domain.Post{ID: postID, UserID: owner.UserID}}, nil)
 	mockPostsRepo.EXPECT().Delete(ctx, postID).Return(nil)
 
-	err := svc.Delete(ctx, postID)
+	err := svc.Delete(ctx, owner, postID)
 	require.NoError(t, err)
 }
 
+func TestService_Delete_Forbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostsRepo := mocks.NewMockpostsRepo(ctrl)
+	mockUsersRepo := mocks.NewMockusersRepo(ctrl)
+
+	logger := zap.NewNop()
+	svc := postsservice.New(mockPostsRepo, mockUsersRepo, logger)
+
+	ctx := context.Background()
+	postID := uuid.NewString()
+
+	mockPostsRepo.EXPECT().Get(ctx, postID).Return(&domain.PostDetail{Post: domain.Post{ID: postID, UserID: uuid.NewString()}}, nil)
+
+	err := svc.Delete(ctx, domain.Principal{UserID: uuid.NewString(), Role: domain.RoleUser}, postID)
+	require.ErrorIs(t, err, domain.ErrPostForbidden)
+}
+
 func TestService_Delete_NotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -131,9 +251,74 @@ func TestService_Delete_NotFound(t *testing.T) {
 	ctx := context.Background()
 	postID := uuid.NewString()
 
-	mockPostsRepo.EXPECT().Delete(ctx, postID).Return(gorm.ErrRecordNotFound)
+	mockPostsRepo.EXPECT().Get(ctx, postID).Return(nil, gorm.ErrRecordNotFound)
 
-	err := svc.Delete(ctx, postID)
+	err := svc.Delete(ctx, domain.Principal{UserID: uuid.NewString(), Role: domain.RoleAdmin}, postID)
 	require.Error(t, err)
-	require.Equal(t, domain.ErrUserNotFound, err)
-}
\ No newline at end of file
+	require.Equal(t, domain.ErrPostNotFound, err)
+}
+func TestService_Get_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostsRepo := mocks.NewMockpostsRepo(ctrl)
+	mockUsersRepo := mocks.NewMockusersRepo(ctrl)
+
+	logger := zap.NewNop()
+	svc := postsservice.New(mockPostsRepo, mockUsersRepo, logger)
+
+	ctx := context.Background()
+	postID := uuid.NewString()
+
+	mockPostsRepo.EXPECT().Get(ctx, postID).Return(nil, gorm.ErrRecordNotFound)
+
+	post, err := svc.Get(ctx, postID)
+	require.Nil(t, post)
+	require.Equal(t, domain.ErrPostNotFound, err)
+}
+
+func TestService_ListRevisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPostsRepo := mocks.NewMockpostsRepo(ctrl)
+	mockUsersRepo := mocks.NewMockusersRepo(ctrl)
+
+	logger := zap.NewNop()
+	svc := postsservice.New(mockPostsRepo, mockUsersRepo, logger)
+
+	ctx := context.Background()
+	postID := uuid.NewString()
+
+	current := &domain.PostDetail{Post: domain.Post{ID: postID, Title: "Title v3", Body: "line 1\nline 2 edited"}}
+	revisions := []domain.PostRevision{
+		{PostID: postID, Version: 1, Title: "Title v1", Body: "line 1"},
+		{PostID: postID, Version: 2, Title: "Title v3", Body: "line 1\nline 2"},
+	}
+
+	mockPostsRepo.EXPECT().Get(ctx, postID).Return(current, nil)
+	mockPostsRepo.EXPECT().ListRevisions(ctx, postID).Return(revisions, nil)
+
+	details, err := svc.ListRevisions(ctx, postID)
+	require.NoError(t, err)
+	require.Len(t, details, 2)
+
+	// Version 1 was replaced by version 2.
+	require.Equal(t, revisions[0], details[0].PostRevision)
+	require.Equal(t, []domain.DiffLine{
+		{Op: domain.DiffDelete, Text: "Title v1"},
+		{Op: domain.DiffInsert, Text: "Title v3"},
+	}, details[0].Changes.Title)
+	require.Equal(t, []domain.DiffLine{
+		{Op: domain.DiffEqual, Text: "line 1"},
+		{Op: domain.DiffInsert, Text: "line 2"},
+	}, details[0].Changes.Body)
+
+	// Version 2 was replaced by the current post.
+	require.Equal(t, []domain.DiffLine{{Op: domain.DiffEqual, Text: "Title v3"}}, details[1].Changes.Title)
+	require.Equal(t, []domain.DiffLine{
+		{Op: domain.DiffEqual, Text: "line 1"},
+		{Op: domain.DiffDelete, Text: "line 2"},
+		{Op: domain.DiffInsert, Text: "line 2 edited"},
+	}, details[1].Changes.Body)
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/victor-nach/postr-backend/internal/services/quotasservice (interfaces: quotasRepo)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_repo.go -package=mocks github.com/victor-nach/postr-backend/internal/services/quotasservice quotasRepo
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockquotasRepo is a mock of quotasRepo interface.
+type MockquotasRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockquotasRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockquotasRepoMockRecorder is the mock recorder for MockquotasRepo.
+type MockquotasRepoMockRecorder struct {
+	mock *MockquotasRepo
+}
+
+// NewMockquotasRepo creates a new mock instance.
+func NewMockquotasRepo(ctrl *gomock.Controller) *MockquotasRepo {
+	mock := &MockquotasRepo{ctrl: ctrl}
+	mock.recorder = &MockquotasRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockquotasRepo) EXPECT() *MockquotasRepoMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockquotasRepo) Consume(ctx context.Context, keyID, kind, period string, limit int) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, keyID, kind, period, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockquotasRepoMockRecorder) Consume(ctx, keyID, kind, period, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockquotasRepo)(nil).Consume), ctx, keyID, kind, period, limit)
+}
+
+// Get mocks base method.
+func (m *MockquotasRepo) Get(ctx context.Context, keyID, kind, period string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, keyID, kind, period)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockquotasRepoMockRecorder) Get(ctx, keyID, kind, period any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockquotasRepo)(nil).Get), ctx, keyID, kind, period)
+}
+
+// Refund mocks base method.
+func (m *MockquotasRepo) Refund(ctx context.Context, keyID, kind, period string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, keyID, kind, period)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockquotasRepoMockRecorder) Refund(ctx, keyID, kind, period any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockquotasRepo)(nil).Refund), ctx, keyID, kind, period)
+}
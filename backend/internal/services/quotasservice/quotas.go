@@ -0,0 +1,118 @@
+package quotasservice
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+)
+
+// Limits are the budgets every API key gets
+type Limits struct {
+	MonthlyRequests int
+	DailyPosts      int
+}
+
+type service struct {
+	repo   quotasRepo
+	limits Limits
+	now    func() time.Time
+	logger *zap.Logger
+}
+
+func New(repo quotasRepo, limits Limits, logger *zap.Logger) domain.QuotaService {
+	logger = logger.With(zap.String("package", "quotasservice"))
+
+	return &service{
+		repo:   repo,
+		limits: limits,
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+//go:generate mockgen -destination=./mocks/mock_repo.go -package=mocks github.com/victor-nach/postr-backend/internal/services/quotasservice quotasRepo
+type quotasRepo interface {
+	Consume(ctx context.Context, keyID, kind, period string, limit int) (int, bool, error)
+	Refund(ctx context.Context, keyID, kind, period string) error
+	Get(ctx context.Context, keyID, kind, period string) (int, error)
+}
+
+// Consume uses one of the key's quota for the current period. When the quota is used up it
+// returns domain.ErrQuotaExceeded along with the usage, so callers can tell when it resets.
+func (s *service) Consume(ctx context.Context, keyID string, kind domain.QuotaKind) (*domain.QuotaUsage, error) {
+	logr := s.logger.With(zap.String("method", "Consume"))
+
+	period, resetsAt, limit := s.period(kind)
+	used, ok, err := s.repo.Consume(ctx, keyID, string(kind), period, limit)
+	if err != nil {
+		logr.Error("Error consuming quota", zap.Error(err), zap.String("api_key_id", keyID), zap.String("kind", string(kind)))
+		return nil, domain.ErrInternalServer
+	}
+
+	usage := newUsage(kind, period, used, limit, resetsAt)
+	if !ok {
+		logr.Info("Quota exceeded", zap.String("api_key_id", keyID), zap.String("kind", string(kind)), zap.String("period", period))
+		return usage, domain.ErrQuotaExceededFor(kind)
+	}
+
+	return usage, nil
+}
+
+// Refund gives back a use of the quota for a request that failed. period is the one Consume
+// counted the request in, so a request that straddles midnight is refunded to the right day.
+func (s *service) Refund(ctx context.Context, keyID string, kind domain.QuotaKind, period string) error {
+	logr := s.logger.With(zap.String("method", "Refund"))
+
+	if err := s.repo.Refund(ctx, keyID, string(kind), period); err != nil {
+		logr.Error("Error refunding quota", zap.Error(err), zap.String("api_key_id", keyID), zap.String("kind", string(kind)))
+		return domain.ErrInternalServer
+	}
+
+	return nil
+}
+
+// Usage returns the key's use of every quota in the current periods
+func (s *service) Usage(ctx context.Context, keyID string) ([]domain.QuotaUsage, error) {
+	logr := s.logger.With(zap.String("method", "Usage"))
+
+	kinds := []domain.QuotaKind{domain.QuotaRequests, domain.QuotaPosts}
+	usage := make([]domain.QuotaUsage, 0, len(kinds))
+	for _, kind := range kinds {
+		period, resetsAt, limit := s.period(kind)
+		used, err := s.repo.Get(ctx, keyID, string(kind), period)
+		if err != nil {
+			logr.Error("Error retrieving quota usage", zap.Error(err), zap.String("api_key_id", keyID), zap.String("kind", string(kind)))
+			return nil, domain.ErrInternalServer
+		}
+		usage = append(usage, *newUsage(kind, period, used, limit, resetsAt))
+	}
+
+	return usage, nil
+}
+
+// period returns the current period of the quota, when the next one starts and its limit
+func (s *service) period(kind domain.QuotaKind) (string, time.Time, int) {
+	now := s.now().UTC()
+
+	if kind == domain.QuotaPosts {
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return day.Format(time.DateOnly), day.AddDate(0, 0, 1), s.limits.DailyPosts
+	}
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return month.Format("2006-01"), month.AddDate(0, 1, 0), s.limits.MonthlyRequests
+}
+
+func newUsage(kind domain.QuotaKind, period string, used, limit int, resetsAt time.Time) *domain.QuotaUsage {
+	return &domain.QuotaUsage{
+		Kind:      kind,
+		Period:    period,
+		Used:      used,
+		Limit:     limit,
+		Remaining: max(limit-used, 0),
+		ResetsAt:  resetsAt.Format(time.RFC3339),
+	}
+}
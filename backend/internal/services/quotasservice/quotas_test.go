@@ -0,0 +1,79 @@
+package quotasservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/victor-nach/postr-backend/internal/domain"
+	"github.com/victor-nach/postr-backend/internal/services/quotasservice/mocks"
+)
+
+func newTestService(t *testing.T) (*service, *mocks.MockquotasRepo) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockRepo := mocks.NewMockquotasRepo(ctrl)
+	svc := New(mockRepo, Limits{MonthlyRequests: 1000, DailyPosts: 10}, zap.NewNop()).(*service)
+	svc.now = func() time.Time { return time.Date(2025, 1, 31, 22, 30, 0, 0, time.UTC) }
+	return svc, mockRepo
+}
+
+func TestService_Consume(t *testing.T) {
+	svc, mockRepo := newTestService(t)
+	ctx := context.Background()
+
+	mockRepo.EXPECT().Consume(ctx, "key-1", "requests", "2025-01", 1000).Return(1, true, nil)
+	usage, err := svc.Consume(ctx, "key-1", domain.QuotaRequests)
+	require.NoError(t, err)
+	require.Equal(t, &domain.QuotaUsage{
+		Kind:      domain.QuotaRequests,
+		Period:    "2025-01",
+		Used:      1,
+		Limit:     1000,
+		Remaining: 999,
+		ResetsAt:  "2025-02-01T00:00:00Z",
+	}, usage)
+
+	mockRepo.EXPECT().Consume(ctx, "key-1", "posts", "2025-01-31", 10).Return(10, false, nil)
+	usage, err = svc.Consume(ctx, "key-1", domain.QuotaPosts)
+	require.ErrorIs(t, err, domain.ErrQuotaExceeded)
+	require.Equal(t, 0, usage.Remaining)
+	require.Equal(t, "2025-02-01T00:00:00Z", usage.ResetsAt)
+
+	mockRepo.EXPECT().Consume(ctx, "key-1", "posts", "2025-01-31", 10).Return(0, false, errors.New("database is locked"))
+	_, err = svc.Consume(ctx, "key-1", domain.QuotaPosts)
+	require.ErrorIs(t, err, domain.ErrInternalServer)
+}
+
+func TestService_Refund(t *testing.T) {
+	svc, mockRepo := newTestService(t)
+	ctx := context.Background()
+
+	// the period is the one the request was counted in, not the current one
+	mockRepo.EXPECT().Refund(ctx, "key-1", "posts", "2025-01-30").Return(nil)
+	require.NoError(t, svc.Refund(ctx, "key-1", domain.QuotaPosts, "2025-01-30"))
+
+	mockRepo.EXPECT().Refund(ctx, "key-1", "posts", "2025-01-31").Return(errors.New("database is locked"))
+	require.ErrorIs(t, svc.Refund(ctx, "key-1", domain.QuotaPosts, "2025-01-31"), domain.ErrInternalServer)
+}
+
+func TestService_Usage(t *testing.T) {
+	svc, mockRepo := newTestService(t)
+	ctx := context.Background()
+
+	mockRepo.EXPECT().Get(ctx, "key-1", "requests", "2025-01").Return(250, nil)
+	mockRepo.EXPECT().Get(ctx, "key-1", "posts", "2025-01-31").Return(0, nil)
+
+	usage, err := svc.Usage(ctx, "key-1")
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	require.Equal(t, 750, usage[0].Remaining)
+	require.Equal(t, domain.QuotaPosts, usage[1].Kind)
+	require.Equal(t, 10, usage[1].Remaining)
+}
@@ -70,6 +70,20 @@ func (mr *MockusersRepoMockRecorder) Create(ctx, user any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockusersRepo)(nil).Create), ctx, user)
 }
 
+// Delete mocks base method.
+func (m *MockusersRepo) Delete(ctx context.Context, id string, opts domain.DeleteUserOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id, opts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockusersRepoMockRecorder) Delete(ctx, id, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockusersRepo)(nil).Delete), ctx, id, opts)
+}
+
 // Get mocks base method.
 func (m *MockusersRepo) Get(ctx context.Context, id string) (*domain.User, error) {
 	m.ctrl.T.Helper()
@@ -86,18 +100,47 @@ func (mr *MockusersRepoMockRecorder) Get(ctx, id any) *gomock.Call {
 }
 
 // List mocks base method.
-func (m *MockusersRepo) List(ctx context.Context, pageNumber, pageSize int) (domain.PaginatedUsers, error) {
+func (m *MockusersRepo) List(ctx context.Context, params domain.ListUsersParams) (domain.PaginatedUsers, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", ctx, pageNumber, pageSize)
+	ret := m.ctrl.Call(m, "List", ctx, params)
 	ret0, _ := ret[0].(domain.PaginatedUsers)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockusersRepoMockRecorder) List(ctx, pageNumber, pageSize any) *gomock.Call {
+func (mr *MockusersRepoMockRecorder) List(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockusersRepo)(nil).List), ctx, params)
+}
+
+// ListByCursor mocks base method.
+func (m *MockusersRepo) ListByCursor(ctx context.Context, params domain.CursorParams) (domain.PaginatedUsers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByCursor", ctx, params)
+	ret0, _ := ret[0].(domain.PaginatedUsers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByCursor indicates an expected call of ListByCursor.
+func (mr *MockusersRepoMockRecorder) ListByCursor(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByCursor", reflect.TypeOf((*MockusersRepo)(nil).ListByCursor), ctx, params)
+}
+
+// Update mocks base method.
+func (m *MockusersRepo) Update(ctx context.Context, user *domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockusersRepoMockRecorder) Update(ctx, user any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockusersRepo)(nil).List), ctx, pageNumber, pageSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockusersRepo)(nil).Update), ctx, user)
 }
 
 // Validate mocks base method.
@@ -25,9 +25,13 @@ func New(repo usersRepo, logger *zap.Logger) domain.UserService {
 //go:generate mockgen -destination=./mocks/mock_repo.go -package=mocks github.com/victor-nach/postr-backend/internal/services/usersservice usersRepo
 type usersRepo interface {
 	Get(ctx context.Context, id string) (*domain.User, error)
-	List(ctx context.Context, pageNumber int, pageSize int) (domain.PaginatedUsers, error)
+	List(ctx context.Context, params domain.ListUsersParams) (domain.PaginatedUsers, error)
+	ListByCursor(ctx context.Context, params domain.CursorParams) (domain.PaginatedUsers, error)
 	Count(ctx context.Context, ) (int, error)
 	Validate(ctx context.Context, userID string) error
+	Create(ctx context.Context, user *domain.User) error
+	Update(ctx context.Context, user *domain.User) error
+	Delete(ctx context.Context, id string, opts domain.DeleteUserOptions) error
 }
 
 func (h *service) Get(ctx context.Context, id string) (*domain.User, error) {
@@ -49,10 +53,10 @@ func (h *service) Get(ctx context.Context, id string) (*domain.User, error) {
 	return user, nil
 }
 
-func (h *service) List(ctx context.Context, pageNumber int, pageSize int) (domain.PaginatedUsers, error) {
+func (h *service) List(ctx context.Context, params domain.ListUsersParams) (domain.PaginatedUsers, error) {
 	logr := h.logger.With(zap.String("method", "List"))
 
-	paginatedUsers, err := h.repo.List(ctx, pageNumber, pageSize)
+	paginatedUsers, err := h.repo.List(ctx, params)
 	if err != nil {
 		logr.Error("Error listing users", zap.Error(err))
 		return domain.PaginatedUsers{}, domain.ErrInternalServer
@@ -62,6 +66,19 @@ func (h *service) List(ctx context.Context, pageNumber int, pageSize int) (domai
 	return paginatedUsers, nil
 }
 
+func (h *service) ListByCursor(ctx context.Context, params domain.CursorParams) (domain.PaginatedUsers, error) {
+	logr := h.logger.With(zap.String("method", "ListByCursor"))
+
+	paginatedUsers, err := h.repo.ListByCursor(ctx, params)
+	if err != nil {
+		logr.Error("Error listing users by cursor", zap.Error(err))
+		return domain.PaginatedUsers{}, domain.ErrInternalServer
+	}
+
+	logr.Info("Users listed successfully", zap.Int("count", len(paginatedUsers.Users)))
+	return paginatedUsers, nil
+}
+
 func (h *service) Count(ctx context.Context) (int, error) {
 	logr := h.logger.With(zap.String("method", "Count"))
 
@@ -73,4 +90,83 @@ func (h *service) Count(ctx context.Context) (int, error) {
 
 	logr.Info("Users count retrieved successfully", zap.Int("count", count))
 	return count, nil
-}
\ No newline at end of file
+}
+
+func (h *service) Create(ctx context.Context, user *domain.User) error {
+	logr := h.logger.With(zap.String("method", "Create"))
+
+	if err := h.repo.Create(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrUserAlreadyExists) {
+			logr.Info("User already exists", zap.String("username", user.Username), zap.String("email", user.Email))
+			return err
+		}
+
+		logr.Error("Error creating user", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	logr.Info("User created successfully", zap.Any("user", user))
+	return nil
+}
+
+func (h *service) Update(ctx context.Context, actor domain.Principal, user *domain.User) error {
+	logr := h.logger.With(zap.String("method", "Update"))
+
+	if !actor.CanModify(user.ID) {
+		logr.Warn("Principal cannot modify another user", zap.String("principal", actor.UserID), zap.String("id", user.ID))
+		return domain.ErrUserForbidden
+	}
+
+	if err := h.repo.Update(ctx, user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("User not found", zap.String("id", user.ID))
+			return domain.ErrUserNotFound
+		}
+
+		if errors.Is(err, domain.ErrUserAlreadyExists) {
+			logr.Info("User already exists", zap.String("username", user.Username), zap.String("email", user.Email))
+			return err
+		}
+
+		logr.Error("Error updating user", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	logr.Info("User updated successfully", zap.Any("user", user))
+	return nil
+}
+
+func (h *service) Delete(ctx context.Context, actor domain.Principal, id string, opts domain.DeleteUserOptions) error {
+	logr := h.logger.With(zap.String("method", "Delete"))
+
+	if !actor.CanModify(id) {
+		logr.Warn("Principal cannot delete another user", zap.String("principal", actor.UserID), zap.String("id", id))
+		return domain.ErrUserForbidden
+	}
+
+	// Reassigning hands the posts to someone else, which only an admin may do
+	if opts.Mode == domain.UserDeleteReassign && opts.ReassignTo != id && actor.Role != domain.RoleAdmin {
+		logr.Warn("Principal cannot reassign posts to another user", zap.String("principal", actor.UserID), zap.String("reassign_to", opts.ReassignTo))
+		return domain.ErrReassignForbidden
+	}
+
+	if opts.Mode == domain.UserDeleteReassign {
+		if err := h.repo.Validate(ctx, opts.ReassignTo); err != nil {
+			logr.Info("Reassign target not found", zap.String("reassign_to", opts.ReassignTo), zap.Error(err))
+			return domain.ErrInvalidInputWithStr("reassignTo user not found")
+		}
+	}
+
+	if err := h.repo.Delete(ctx, id, opts); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logr.Info("User not found", zap.String("id", id))
+			return domain.ErrUserNotFound
+		}
+
+		logr.Error("Error deleting user", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	logr.Info("User deleted successfully", zap.String("id", id), zap.String("mode", string(opts.Mode)))
+	return nil
+}
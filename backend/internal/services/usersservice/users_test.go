@@ -67,7 +67,7 @@ func TestService_List(t *testing.T) {
 	svc := New(mockRepo, logger)
 
 	ctx := context.Background()
-	pageNumber, pageSize := 1, 10
+	params := domain.ListUsersParams{PageNumber: 1, PageSize: 10, Sort: domain.UserSortCreatedAt, Order: domain.SortDesc}
 
 	expectedPaginated := domain.PaginatedUsers{
 		Pagination: domain.Pagination{
@@ -85,9 +85,9 @@ func TestService_List(t *testing.T) {
 		},
 	}
 
-	mockRepo.EXPECT().List(ctx, pageNumber, pageSize).Return(expectedPaginated, nil)
+	mockRepo.EXPECT().List(ctx, params).Return(expectedPaginated, nil)
 
-	result, err := svc.List(ctx, pageNumber, pageSize)
+	result, err := svc.List(ctx, params)
 	require.NoError(t, err)
 	require.Equal(t, expectedPaginated, result)
 }
@@ -109,3 +109,169 @@ func TestService_Count(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedCount, count)
 }
+
+func TestService_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	svc := New(mockRepo, logger)
+
+	ctx := context.Background()
+	user := &domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Dana",
+		Username: "dana",
+		Email:    "dana@example.com",
+	}
+
+	mockRepo.EXPECT().Create(ctx, user).Return(nil)
+
+	err := svc.Create(ctx, user)
+	require.NoError(t, err)
+}
+
+func TestService_Create_Conflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	svc := New(mockRepo, logger)
+
+	ctx := context.Background()
+	user := &domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Dana",
+		Username: "dana",
+		Email:    "dana@example.com",
+	}
+
+	mockRepo.EXPECT().Create(ctx, user).Return(domain.ErrUserAlreadyExists)
+
+	err := svc.Create(ctx, user)
+	require.ErrorIs(t, err, domain.ErrUserAlreadyExists)
+}
+
+func TestService_Update_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	svc := New(mockRepo, logger)
+
+	ctx := context.Background()
+	user := &domain.User{
+		ID:       uuid.NewString(),
+		Name:     "Dana",
+		Username: "dana",
+		Email:    "dana@example.com",
+	}
+
+	mockRepo.EXPECT().Update(ctx, user).Return(gorm.ErrRecordNotFound)
+
+	err := svc.Update(ctx, domain.Principal{UserID: user.ID, Role: domain.RoleUser}, user)
+	require.Equal(t, domain.ErrUserNotFound, err)
+}
+
+func TestService_Delete_ReassignTargetNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	svc := New(mockRepo, logger)
+
+	ctx := context.Background()
+	userID, targetID := uuid.NewString(), uuid.NewString()
+	opts := domain.DeleteUserOptions{Mode: domain.UserDeleteReassign, ReassignTo: targetID}
+
+	mockRepo.EXPECT().Validate(ctx, targetID).Return(domain.ErrUserNotFound)
+
+	err := svc.Delete(ctx, domain.Principal{UserID: uuid.NewString(), Role: domain.RoleAdmin}, userID, opts)
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestService_Update_Forbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	svc := New(mockRepo, logger)
+
+	user := &domain.User{ID: uuid.NewString(), Name: "Dana", Username: "dana", Email: "dana@example.com"}
+	actor := domain.Principal{UserID: uuid.NewString(), Role: domain.RoleUser}
+
+	err := svc.Update(context.Background(), actor, user)
+	require.ErrorIs(t, err, domain.ErrUserForbidden)
+}
+
+func TestService_Update_Admin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	svc := New(mockRepo, logger)
+
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.NewString(), Name: "Dana", Username: "dana", Email: "dana@example.com"}
+	actor := domain.Principal{UserID: uuid.NewString(), Role: domain.RoleAdmin}
+
+	mockRepo.EXPECT().Update(ctx, user).Return(nil)
+
+	err := svc.Update(ctx, actor, user)
+	require.NoError(t, err)
+}
+
+func TestService_Delete_Forbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	svc := New(mockRepo, logger)
+
+	actor := domain.Principal{UserID: uuid.NewString(), Role: domain.RoleUser}
+
+	err := svc.Delete(context.Background(), actor, uuid.NewString(), domain.DeleteUserOptions{Mode: domain.UserDeleteCascade})
+	require.ErrorIs(t, err, domain.ErrUserForbidden)
+}
+
+func TestService_Delete_ReassignForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	svc := New(mockRepo, logger)
+
+	userID, targetID := uuid.NewString(), uuid.NewString()
+	actor := domain.Principal{UserID: userID, Role: domain.RoleUser}
+	opts := domain.DeleteUserOptions{Mode: domain.UserDeleteReassign, ReassignTo: targetID}
+
+	err := svc.Delete(context.Background(), actor, userID, opts)
+	require.ErrorIs(t, err, domain.ErrReassignForbidden)
+}
+
+func TestService_Delete_Self(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockusersRepo(ctrl)
+	logger := zap.NewNop()
+	svc := New(mockRepo, logger)
+
+	ctx := context.Background()
+	userID := uuid.NewString()
+	actor := domain.Principal{UserID: userID, Role: domain.RoleUser}
+	opts := domain.DeleteUserOptions{Mode: domain.UserDeleteCascade}
+
+	mockRepo.EXPECT().Delete(ctx, userID, opts).Return(nil)
+
+	err := svc.Delete(ctx, actor, userID, opts)
+	require.NoError(t, err)
+}
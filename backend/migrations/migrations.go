@@ -0,0 +1,22 @@
+// Package migrations embeds the SQL migrations so the binaries run from any directory. Each
+// database driver has its own set under a directory of its name, with the same versions.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// FS holds the up and down migrations of every driver, see For
+//
+//go:embed sqlite/*.sql postgres/*.sql
+var FS embed.FS
+
+// For returns the migrations of driver, sqlite or postgres, at the root of the returned FS
+func For(driver string) (fs.FS, error) {
+	if _, err := fs.Stat(FS, driver); err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+	return fs.Sub(FS, driver)
+}
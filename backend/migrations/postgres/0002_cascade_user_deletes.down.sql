@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_posts_user_id;
+DROP INDEX IF EXISTS idx_addresses_user_id;
+
+ALTER TABLE posts DROP CONSTRAINT posts_user_id_fkey,
+    ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE addresses DROP CONSTRAINT addresses_user_id_fkey,
+    ADD CONSTRAINT addresses_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
@@ -0,0 +1,9 @@
+-- Deleting a user deletes their address and posts
+ALTER TABLE addresses DROP CONSTRAINT addresses_user_id_fkey,
+    ADD CONSTRAINT addresses_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE posts DROP CONSTRAINT posts_user_id_fkey,
+    ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_addresses_user_id ON addresses(user_id);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id);
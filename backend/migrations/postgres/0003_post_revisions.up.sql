@@ -0,0 +1,12 @@
+ALTER TABLE posts ADD COLUMN updated_at TIMESTAMPTZ;
+
+-- Create post_revisions table, each row holds a previous version of a post
+CREATE TABLE IF NOT EXISTS post_revisions (
+    post_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (post_id, version),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- created_at is a timestamptz, so the feed orders and filters on the column itself
+CREATE INDEX IF NOT EXISTS idx_posts_feed ON posts (created_at DESC, id DESC);
@@ -0,0 +1,7 @@
+-- Full-text index over post titles and bodies, title matches are weighted above body matches.
+-- Postgres keeps the generated column in sync with posts itself.
+ALTER TABLE posts ADD COLUMN search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', body), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_posts_search ON posts USING GIN (search);
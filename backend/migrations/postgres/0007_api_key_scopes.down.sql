@@ -0,0 +1,5 @@
+ALTER TABLE api_keys ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+
+UPDATE api_keys SET role = 'admin' WHERE ' ' || scopes || ' ' LIKE '% admin %';
+
+ALTER TABLE api_keys DROP COLUMN scopes;
@@ -0,0 +1,10 @@
+-- Keys carry a space separated list of scopes instead of a role. Existing keys keep the
+-- access they had, admins get the admin scope and everyone else read and write access.
+ALTER TABLE api_keys ADD COLUMN scopes TEXT NOT NULL DEFAULT '';
+
+UPDATE api_keys SET scopes = CASE role
+    WHEN 'admin' THEN 'admin'
+    ELSE 'users:read users:write posts:read posts:write'
+END;
+
+ALTER TABLE api_keys DROP COLUMN role;
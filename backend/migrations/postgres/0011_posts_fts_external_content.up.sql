@@ -0,0 +1,2 @@
+-- The sqlite search index is rebuilt in this version, the postgres one needs no change.
+SELECT 1;
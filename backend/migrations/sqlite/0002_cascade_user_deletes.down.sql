@@ -0,0 +1,33 @@
+-- Rebuild addresses and posts without the cascade rules
+
+DROP INDEX IF EXISTS idx_posts_user_id;
+DROP INDEX IF EXISTS idx_addresses_user_id;
+
+ALTER TABLE posts RENAME TO posts_old;
+ALTER TABLE addresses RENAME TO addresses_old;
+
+CREATE TABLE addresses (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    street TEXT NOT NULL,
+    city TEXT NOT NULL,
+    state TEXT NOT NULL,
+    zipcode TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE posts (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+INSERT INTO addresses SELECT * FROM addresses_old;
+INSERT INTO posts SELECT * FROM posts_old;
+
+DROP TABLE posts_old;
+DROP TABLE addresses_old;
@@ -0,0 +1,57 @@
+-- SQLite cannot alter foreign keys in place, so the tables are rebuilt with
+-- ON DELETE CASCADE rules. The old tables are renamed first so that the existing
+-- references follow them and dropping them does not cascade into the new tables.
+-- Only columns present in every deployed schema are copied; users and addresses
+-- without a created_at column get the migration time.
+
+ALTER TABLE posts RENAME TO posts_old;
+ALTER TABLE addresses RENAME TO addresses_old;
+ALTER TABLE users RENAME TO users_old;
+
+-- Create users table
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    username TEXT NOT NULL UNIQUE,
+    email TEXT NOT NULL UNIQUE,
+    phone TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create addresses table
+CREATE TABLE addresses (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    street TEXT NOT NULL,
+    city TEXT NOT NULL,
+    state TEXT NOT NULL,
+    zipcode TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create posts table
+CREATE TABLE posts (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO users (id, name, username, email, phone)
+SELECT id, name, username, email, phone FROM users_old;
+
+INSERT INTO addresses (id, user_id, street, city, state, zipcode)
+SELECT id, user_id, street, city, state, zipcode FROM addresses_old;
+
+INSERT INTO posts (id, user_id, title, body, created_at)
+SELECT id, user_id, title, body, created_at FROM posts_old;
+
+DROP TABLE posts_old;
+DROP TABLE addresses_old;
+DROP TABLE users_old;
+
+CREATE INDEX IF NOT EXISTS idx_addresses_user_id ON addresses(user_id);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id);
@@ -0,0 +1,3 @@
+-- created_at is stored as RFC3339 text with mixed offsets, so the feed orders and filters on
+-- its normalised UTC value and indexes that expression
+CREATE INDEX IF NOT EXISTS idx_posts_feed ON posts (datetime(created_at) DESC, id DESC);
@@ -0,0 +1,14 @@
+-- API keys are stored as a salted hash, prefix is the public part of the key used to look it up
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    role TEXT NOT NULL DEFAULT 'user',
+    prefix TEXT NOT NULL UNIQUE,
+    salt TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    revoked_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner_id ON api_keys (owner_id);
@@ -0,0 +1,5 @@
+-- Keys created from now on expire, existing keys keep a NULL expires_at and never expire.
+-- last_used_at and last_used_ip are written in batches, so they can lag a little behind.
+ALTER TABLE api_keys ADD COLUMN expires_at DATETIME;
+ALTER TABLE api_keys ADD COLUMN last_used_at DATETIME;
+ALTER TABLE api_keys ADD COLUMN last_used_ip TEXT;
@@ -0,0 +1,10 @@
+-- How much of each quota a key has used per period, a month such as 2025-01 for requests and a
+-- day such as 2025-01-31 for posts. Rows of past periods are left behind and no longer read.
+CREATE TABLE IF NOT EXISTS api_key_quotas (
+    api_key_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    period TEXT NOT NULL,
+    used INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, kind, period),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys(id) ON DELETE CASCADE
+);
@@ -0,0 +1,27 @@
+DROP TRIGGER IF EXISTS posts_fts_update;
+DROP TRIGGER IF EXISTS posts_fts_delete;
+DROP TRIGGER IF EXISTS posts_fts_insert;
+DROP TABLE IF EXISTS posts_fts;
+
+CREATE VIRTUAL TABLE posts_fts USING fts5(
+    post_id UNINDEXED,
+    title,
+    body,
+    tokenize = 'porter unicode61'
+);
+
+INSERT INTO posts_fts (post_id, title, body)
+SELECT id, title, body FROM posts;
+
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts (post_id, title, body) VALUES (new.id, new.title, new.body);
+END;
+
+CREATE TRIGGER posts_fts_delete AFTER DELETE ON posts BEGIN
+    DELETE FROM posts_fts WHERE post_id = old.id;
+END;
+
+CREATE TRIGGER posts_fts_update AFTER UPDATE OF id, title, body ON posts BEGIN
+    DELETE FROM posts_fts WHERE post_id = old.id;
+    INSERT INTO posts_fts (post_id, title, body) VALUES (new.id, new.title, new.body);
+END;
@@ -0,0 +1,32 @@
+-- posts_fts becomes an external content table that reads titles and bodies from posts and
+-- shares its rowids, so the triggers update the index by rowid instead of scanning it for
+-- post_id. VACUUM may renumber the rowids of posts, run
+-- INSERT INTO posts_fts (posts_fts) VALUES ('rebuild') after one.
+DROP TRIGGER IF EXISTS posts_fts_update;
+DROP TRIGGER IF EXISTS posts_fts_delete;
+DROP TRIGGER IF EXISTS posts_fts_insert;
+DROP TABLE IF EXISTS posts_fts;
+
+CREATE VIRTUAL TABLE posts_fts USING fts5(
+    title,
+    body,
+    content = 'posts',
+    content_rowid = 'rowid',
+    tokenize = 'porter unicode61'
+);
+
+INSERT INTO posts_fts (posts_fts) VALUES ('rebuild');
+
+-- Keep posts_fts in sync with posts, deletes must pass the old values
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts (rowid, title, body) VALUES (new.rowid, new.title, new.body);
+END;
+
+CREATE TRIGGER posts_fts_delete AFTER DELETE ON posts BEGIN
+    INSERT INTO posts_fts (posts_fts, rowid, title, body) VALUES ('delete', old.rowid, old.title, old.body);
+END;
+
+CREATE TRIGGER posts_fts_update AFTER UPDATE OF title, body ON posts BEGIN
+    INSERT INTO posts_fts (posts_fts, rowid, title, body) VALUES ('delete', old.rowid, old.title, old.body);
+    INSERT INTO posts_fts (rowid, title, body) VALUES (new.rowid, new.title, new.body);
+END;
@@ -0,0 +1,78 @@
+// Package apikey generates API keys and the salted hashes they are stored as.
+//
+// A key looks like postr_<prefix>_<secret>. The prefix is stored in the clear to look the
+// key up, only a salted SHA-256 hash of the secret is stored.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const keyPrefix = "postr"
+
+var ErrMalformedKey = errors.New("malformed api key")
+
+// Key is a freshly generated API key, Raw is only ever shown to its owner once
+type Key struct {
+	Raw    string
+	Prefix string
+	Salt   string
+	Hash   string
+}
+
+// Generate creates a new random key along with the salt and hash to store
+func Generate() (Key, error) {
+	prefix, err := randomString(6, hex.EncodeToString)
+	if err != nil {
+		return Key{}, err
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return Key{}, err
+	}
+	salt, err := randomString(16, hex.EncodeToString)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Key{
+		Raw:    keyPrefix + "_" + prefix + "_" + secret,
+		Prefix: prefix,
+		Salt:   salt,
+		Hash:   Hash(salt, secret),
+	}, nil
+}
+
+// Parse splits a raw key into its lookup prefix and secret
+func Parse(raw string) (prefix, secret string, err error) {
+	parts := strings.SplitN(raw, "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", ErrMalformedKey
+	}
+	return parts[1], parts[2], nil
+}
+
+// Hash returns the hex encoded salted hash of a secret
+func Hash(salt, secret string) string {
+	sum := sha256.Sum256([]byte(salt + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether the secret matches the stored hash, in constant time
+func Verify(salt, hash, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(salt, secret)), []byte(hash)) == 1
+}
+
+func randomString(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
@@ -0,0 +1,32 @@
+package apikey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	key, err := Generate()
+	require.NoError(t, err)
+
+	prefix, secret, err := Parse(key.Raw)
+	require.NoError(t, err)
+	require.Equal(t, key.Prefix, prefix)
+	require.NotContains(t, key.Hash, secret)
+
+	require.True(t, Verify(key.Salt, key.Hash, secret))
+	require.False(t, Verify(key.Salt, key.Hash, secret+"x"))
+
+	other, err := Generate()
+	require.NoError(t, err)
+	require.NotEqual(t, key.Salt, other.Salt)
+	require.NotEqual(t, key.Prefix, other.Prefix)
+}
+
+func TestParse_Malformed(t *testing.T) {
+	for _, raw := range []string{"", "random_key", "postr__secret", "postr_abc_", "other_abc_secret"} {
+		_, _, err := Parse(raw)
+		require.ErrorIs(t, err, ErrMalformedKey, raw)
+	}
+}
@@ -0,0 +1,65 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidCursor is returned when a cursor is malformed or its signature does not match
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Codec encodes values into opaque, signed cursor strings and decodes them back
+type Codec struct {
+	secret []byte
+}
+
+// NewCodec creates a Codec that signs cursors with the given secret
+func NewCodec(secret []byte) *Codec {
+	return &Codec{secret: secret}
+}
+
+// Encode serialises v to JSON and returns it as a url safe string with an HMAC-SHA256 signature
+func (c *Codec) Encode(v any) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling cursor: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode verifies the signature of token and unmarshals its payload into v
+func (c *Codec) Decode(token string, v any) error {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(payload)) {
+		return ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, v); err != nil {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
+
+func (c *Codec) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
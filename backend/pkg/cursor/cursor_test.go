@@ -0,0 +1,37 @@
+package cursor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type payload struct {
+	ID string `json:"id"`
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+
+	token, err := codec.Encode(payload{ID: "abc"})
+	require.NoError(t, err)
+
+	var decoded payload
+	require.NoError(t, codec.Decode(token, &decoded))
+	require.Equal(t, "abc", decoded.ID)
+}
+
+func TestCodec_RejectsTamperedCursor(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+
+	token, err := codec.Encode(payload{ID: "abc"})
+	require.NoError(t, err)
+
+	forged, err := NewCodec([]byte("other-secret")).Encode(payload{ID: "xyz"})
+	require.NoError(t, err)
+
+	var decoded payload
+	require.ErrorIs(t, codec.Decode(forged, &decoded), ErrInvalidCursor)
+	require.ErrorIs(t, codec.Decode(token[:len(token)-2], &decoded), ErrInvalidCursor)
+	require.ErrorIs(t, codec.Decode("not-a-cursor", &decoded), ErrInvalidCursor)
+}
@@ -0,0 +1,254 @@
+// Package jwtauth verifies bearer JWTs signed with HS256 or RS256.
+//
+// Keys come from a shared secret, a PEM encoded RSA public key or a local JWKS file. Every
+// token must carry a subject and an expiry and be issued for the configured audience.
+package jwtauth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// clockSkew is how far exp and nbf may be off before a token is rejected
+const clockSkew = 30 * time.Second
+
+// ErrInvalidToken is returned for any token that fails verification
+var ErrInvalidToken = errors.New("invalid token")
+
+// Config selects the keys tokens are verified with, at least one key source is required
+type Config struct {
+	// HMACSecret verifies HS256 tokens
+	HMACSecret string
+	// PublicKeyFile is a PEM encoded RSA public key that verifies RS256 tokens
+	PublicKeyFile string
+	// JWKSFile is a JSON Web Key Set, tokens pick a key from it by their kid header
+	JWKSFile string
+	// Audience must appear in the aud claim of every token
+	Audience string
+}
+
+// Enabled reports whether any key source is configured
+func (c Config) Enabled() bool {
+	return c.HMACSecret != "" || c.PublicKeyFile != "" || c.JWKSFile != ""
+}
+
+// Claims are the verified claims the API uses
+type Claims struct {
+	Subject string
+	// Scopes is the space separated scope claim split into its parts
+	Scopes []string
+	// HasScope reports whether the token carries a scope claim, an empty claim grants no scopes
+	HasScope bool
+}
+
+// Verifier checks token signatures and claims
+type Verifier struct {
+	hmacSecret []byte
+	publicKey  *rsa.PublicKey
+	jwks       map[string]any
+	parser     *jwt.Parser
+}
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope *string `json:"scope"`
+}
+
+// New loads the configured keys
+func New(cfg Config) (*Verifier, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("no JWT key configured")
+	}
+	if cfg.Audience == "" {
+		return nil, errors.New("a JWT audience is required")
+	}
+
+	v := &Verifier{}
+	methods := []string{}
+
+	if cfg.HMACSecret != "" {
+		v.hmacSecret = []byte(cfg.HMACSecret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	if cfg.PublicKeyFile != "" {
+		pem, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading public key: %w", err)
+		}
+		v.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing public key: %w", err)
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading JWKS file: %w", err)
+		}
+		v.jwks, err = parseJWKS(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing JWKS file: %w", err)
+		}
+		methods = append(methods, jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg())
+	}
+
+	v.parser = jwt.NewParser(
+		jwt.WithValidMethods(methods),
+		jwt.WithAudience(cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	)
+
+	return v, nil
+}
+
+// Verify checks the token and returns its claims, nbf is checked whenever the token sets it
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	var claims tokenClaims
+	if _, err := v.parser.ParseWithClaims(token, &claims, v.key); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+
+	verified := &Claims{Subject: claims.Subject}
+	if claims.Scope != nil {
+		verified.Scopes = strings.Fields(*claims.Scope)
+		verified.HasScope = true
+	}
+	return verified, nil
+}
+
+// key picks the verification key for a token. A kid header is looked up in the JWKS,
+// tokens without one use the configured secret or public key.
+func (v *Verifier) key(token *jwt.Token) (any, error) {
+	if kid, ok := token.Header["kid"].(string); ok && v.jwks != nil {
+		key, ok := v.jwks[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+		return matchAlg(token, key)
+	}
+
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if v.hmacSecret != nil {
+			return v.hmacSecret, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if v.publicKey != nil {
+			return v.publicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("no key for alg %s", token.Method.Alg())
+}
+
+// matchAlg stops a token from using a JWKS key with a different algorithm than its type,
+// such as an RSA public key passed off as an HMAC secret
+func matchAlg(token *jwt.Token, key any) (any, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if token.Method.Alg() == jwt.SigningMethodRS256.Alg() {
+			return key, nil
+		}
+	case []byte:
+		if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key does not match alg %s", token.Method.Alg())
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// parseJWKS reads the RSA and oct keys of a key set, keyed by kid
+func parseJWKS(data []byte) (map[string]any, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kid == "" {
+			return nil, errors.New("every key needs a kid")
+		}
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: invalid n: %w", k.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: invalid e: %w", k.Kid, err)
+			}
+			keys[k.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: invalid k: %w", k.Kid, err)
+			}
+			keys[k.Kid] = secret
+		default:
+			return nil, fmt.Errorf("key %s: unsupported kty %q", k.Kid, k.Kty)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys found")
+	}
+	return keys, nil
+}
+
+// Signer issues HS256 access tokens that a Verifier with the same secret and audience accepts
+type Signer struct {
+	secret   []byte
+	audience string
+}
+
+func NewSigner(secret, audience string) *Signer {
+	return &Signer{secret: []byte(secret), audience: audience}
+}
+
+// Sign returns a token for the subject that expires after ttl
+func (s *Signer) Sign(subject string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{s.audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
@@ -0,0 +1,184 @@
+package jwtauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const audience = "postr-api"
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user-1",
+		"aud":   audience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "posts:read posts:write",
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestVerifier_HS256(t *testing.T) {
+	v, err := New(Config{HMACSecret: "secret", Audience: audience})
+	require.NoError(t, err)
+
+	claims, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte("secret"), validClaims(), ""))
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+	require.Equal(t, []string{"posts:read", "posts:write"}, claims.Scopes)
+
+	unscoped := validClaims()
+	delete(unscoped, "scope")
+	claims, err = v.Verify(sign(t, jwt.SigningMethodHS256, []byte("secret"), unscoped, ""))
+	require.NoError(t, err)
+	require.Empty(t, claims.Scopes)
+	require.False(t, claims.HasScope)
+
+	empty := validClaims()
+	empty["scope"] = ""
+	claims, err = v.Verify(sign(t, jwt.SigningMethodHS256, []byte("secret"), empty, ""))
+	require.NoError(t, err)
+	require.Empty(t, claims.Scopes)
+	require.True(t, claims.HasScope)
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodHS256, []byte("other-secret"), validClaims(), ""))
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = v.Verify("not-a-token")
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifier_RejectsInvalidClaims(t *testing.T) {
+	v, err := New(Config{HMACSecret: "secret", Audience: audience})
+	require.NoError(t, err)
+
+	tests := map[string]func(jwt.MapClaims){
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"missing exp":    func(c jwt.MapClaims) { delete(c, "exp") },
+		"not yet valid":  func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+		"missing sub":    func(c jwt.MapClaims) { delete(c, "sub") },
+	}
+
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := validClaims()
+			mutate(claims)
+
+			_, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte("secret"), claims, ""))
+			require.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestVerifier_RS256PublicKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	path := writeFile(t, "public.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	v, err := New(Config{PublicKeyFile: path, Audience: audience})
+	require.NoError(t, err)
+
+	claims, err := v.Verify(sign(t, jwt.SigningMethodRS256, privateKey, validClaims(), ""))
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+
+	// HS256 is not enabled, a token signed with the public key as a secret must not pass
+	_, err = v.Verify(sign(t, jwt.SigningMethodHS256, der, validClaims(), ""))
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifier_JWKS(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	encode := base64.RawURLEncoding.EncodeToString
+	set, err := json.Marshal(map[string]any{
+		"keys": []map[string]string{
+			{
+				"kid": "rsa-1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   encode(privateKey.N.Bytes()),
+				"e":   encode(big.NewInt(int64(privateKey.E)).Bytes()),
+			},
+			{"kid": "hmac-1", "kty": "oct", "k": encode([]byte("jwks-secret"))},
+		},
+	})
+	require.NoError(t, err)
+
+	v, err := New(Config{JWKSFile: writeFile(t, "jwks.json", set), Audience: audience})
+	require.NoError(t, err)
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS256, privateKey, validClaims(), "rsa-1"))
+	require.NoError(t, err)
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodHS256, []byte("jwks-secret"), validClaims(), "hmac-1"))
+	require.NoError(t, err)
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS256, privateKey, validClaims(), "unknown"))
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// an HMAC token naming the RSA key must not be checked against it
+	_, err = v.Verify(sign(t, jwt.SigningMethodHS256, []byte("jwks-secret"), validClaims(), "rsa-1"))
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNew_RequiresKeyAndAudience(t *testing.T) {
+	_, err := New(Config{Audience: audience})
+	require.Error(t, err)
+
+	_, err = New(Config{HMACSecret: "secret"})
+	require.Error(t, err)
+}
+
+func TestSigner_RoundTrip(t *testing.T) {
+	v, err := New(Config{HMACSecret: "secret", Audience: audience})
+	require.NoError(t, err)
+
+	token, err := NewSigner("secret", audience).Sign("user-1", time.Minute)
+	require.NoError(t, err)
+
+	claims, err := v.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+	require.False(t, claims.HasScope)
+
+	expired, err := NewSigner("secret", audience).Sign("user-1", -time.Hour)
+	require.NoError(t, err)
+	_, err = v.Verify(expired)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -2,12 +2,20 @@ package logger
 
 import (
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/victor-nach/postr-backend/internal/config"
 )
 
 // NewLogger creates a new zap.Logger instance
 func NewLogger(appEnv string) (*zap.Logger, error) {
+	logger, _, err := NewLeveledLogger(appEnv, "")
+	return logger, err
+}
+
+// NewLeveledLogger creates a new zap.Logger instance logging at level, or at the environment's
+// default when level is empty. The returned level changes the logger's level while it is in use.
+func NewLeveledLogger(appEnv, level string) (*zap.Logger, zap.AtomicLevel, error) {
 	var cfg zap.Config
 	var err error
 
@@ -19,9 +27,15 @@ func NewLogger(appEnv string) (*zap.Logger, error) {
 
 	cfg.DisableStacktrace = true
 
+	lvl, err := ParseLevel(appEnv, level)
+	if err != nil {
+		return nil, cfg.Level, err
+	}
+	cfg.Level.SetLevel(lvl)
+
 	logger, err := cfg.Build()
 	if err != nil {
-		return nil, err
+		return nil, cfg.Level, err
 	}
 
 	logger = logger.With(
@@ -30,5 +44,16 @@ func NewLogger(appEnv string) (*zap.Logger, error) {
 		 zap.String("app_env", appEnv),
 		)
 
-	return logger, nil
+	return logger, cfg.Level, nil
+}
+
+// ParseLevel returns the named level, empty means debug in development and info elsewhere
+func ParseLevel(appEnv, level string) (zapcore.Level, error) {
+	if level != "" {
+		return zapcore.ParseLevel(level)
+	}
+	if appEnv == config.DevEnv {
+		return zapcore.DebugLevel, nil
+	}
+	return zapcore.InfoLevel, nil
 }
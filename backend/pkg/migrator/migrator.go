@@ -4,29 +4,53 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"gorm.io/gorm"
 
 	"github.com/victor-nach/postr-backend/internal/domain"
 )
 
-// Migrate applies all up migrations from the specified migrations path
-func Migrate(db *sql.DB, migrationsPath string) error {
-	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+// Dialects the migrations can be applied to, each has its own set of migrations
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// Migrate applies all up migrations from the specified migrations path, a source URL such as file://migrations/sqlite
+func Migrate(db *sql.DB, dialect, migrationsPath string) error {
+	return run(db, dialect, func(name string, driver database.Driver) (*migrate.Migrate, error) {
+		return migrate.NewWithDatabaseInstance(migrationsPath, name, driver)
+	})
+}
+
+// MigrateFS applies all up migrations found at the root of fsys, such as migrations.For(dialect)
+func MigrateFS(db *sql.DB, dialect string, fsys fs.FS) error {
+	source, err := iofs.New(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("could not read migrations: %w", err)
+	}
+
+	return run(db, dialect, func(name string, driver database.Driver) (*migrate.Migrate, error) {
+		return migrate.NewWithInstance("iofs", source, name, driver)
+	})
+}
+
+func run(db *sql.DB, dialect string, newMigrate func(string, database.Driver) (*migrate.Migrate, error)) error {
+	name, driver, err := databaseDriver(db, dialect)
 	if err != nil {
 		return fmt.Errorf("could not create migration driver: %w", err)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		migrationsPath,
-		"sqlite3",
-		driver,
-	)
+	m, err := newMigrate(name, driver)
 	if err != nil {
 		return fmt.Errorf("failed to create migrate instance: %w", err)
 	}
@@ -39,6 +63,20 @@ func Migrate(db *sql.DB, migrationsPath string) error {
 	return nil
 }
 
+// databaseDriver returns the migrate database driver for dialect and the name it is known by
+func databaseDriver(db *sql.DB, dialect string) (string, database.Driver, error) {
+	switch dialect {
+	case DialectSQLite:
+		driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+		return "sqlite3", driver, err
+	case DialectPostgres:
+		driver, err := pgx.WithInstance(db, &pgx.Config{})
+		return "pgx5", driver, err
+	default:
+		return "", nil, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+}
+
 // Seed seeds the db
 func Seed(db *gorm.DB) error {
 	users, err := readUsersFromJSON("seeds/users.json")
@@ -46,7 +84,8 @@ func Seed(db *gorm.DB) error {
 		return fmt.Errorf("failed to read users from JSON: %w", err)
 	}
 
-	if err := db.Create(&users).Error; err != nil {
+	// the seeds have no created_at, the column default applies
+	if err := db.Omit("created_at").Create(&users).Error; err != nil {
 		return fmt.Errorf("failed to insert users: %w", err)
 	}
 
@@ -55,7 +94,7 @@ func Seed(db *gorm.DB) error {
 		return fmt.Errorf("failed to read posts from JSON: %w", err)
 	}
 
-	if err := db.Create(&posts).Error; err != nil {
+	if err := db.Omit("created_at", "updated_at").Create(&posts).Error; err != nil {
 		return fmt.Errorf("failed to insert posts: %w", err)
 	}
 
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tiers a policy can apply to, callers without a user are anonymous and limited per IP
+const (
+	TierAdmin     = "admin"
+	TierUser      = "user"
+	TierAnonymous = "anonymous"
+)
+
+var tiers = []string{TierAdmin, TierUser, TierAnonymous}
+
+// Policy is the limit for the requests it matches. Empty Group, Methods and Tier match
+// everything. Policies with the same Name share a bucket.
+type Policy struct {
+	Name    string   `yaml:"name" toml:"name"`
+	Group   string   `yaml:"group" toml:"group"`
+	Methods []string `yaml:"methods" toml:"methods"`
+	Tier    string   `yaml:"tier" toml:"tier"`
+	Rate    float64  `yaml:"rate" toml:"rate"`
+	Burst   int      `yaml:"burst" toml:"burst"`
+}
+
+// Limit returns the token bucket of the policy
+func (p Policy) Limit() Limit {
+	return Limit{Rate: p.Rate, Burst: p.Burst}
+}
+
+func (p Policy) matches(group, method, tier string) bool {
+	return (p.Group == "" || p.Group == group) &&
+		(len(p.Methods) == 0 || slices.Contains(p.Methods, method)) &&
+		(p.Tier == "" || p.Tier == tier)
+}
+
+// Policies is a policy table, the first matching rule applies and Default covers the rest
+type Policies struct {
+	Default Policy   `yaml:"default" toml:"default"`
+	Rules   []Policy `yaml:"policies" toml:"policies"`
+}
+
+// SinglePolicy allows rps requests a second with bursts of the same size on every route
+func SinglePolicy(rps int) Policies {
+	return Policies{Default: Policy{Name: "default", Rate: float64(rps), Burst: rps}}
+}
+
+// LoadPolicies reads and validates a YAML policy file
+func LoadPolicies(path string) (Policies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policies{}, fmt.Errorf("error reading rate limit policies: %w", err)
+	}
+
+	var p Policies
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return Policies{}, fmt.Errorf("error parsing rate limit policies: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return Policies{}, fmt.Errorf("invalid rate limit policies in %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Match returns the policy for a request to the route group with the method, made by the tier
+func (p Policies) Match(group, method, tier string) Policy {
+	for _, rule := range p.Rules {
+		if rule.matches(group, method, tier) {
+			return rule
+		}
+	}
+	return p.Default
+}
+
+// Validate names unnamed policies, upper cases methods and checks every limit is usable
+func (p *Policies) Validate() error {
+	if p.Default.Name == "" {
+		p.Default.Name = "default"
+	}
+	if err := p.Default.normalise(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("policy-%d", i+1)
+		}
+		if err := rule.normalise(); err != nil {
+			return fmt.Errorf("policy %q: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *Policy) normalise() error {
+	if p.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if p.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1")
+	}
+	if p.Tier != "" && !slices.Contains(tiers, p.Tier) {
+		return fmt.Errorf("unknown tier %q, expected one of %s", p.Tier, strings.Join(tiers, ", "))
+	}
+
+	p.Group = strings.Trim(p.Group, "/")
+	for i, method := range p.Methods {
+		p.Methods[i] = strings.ToUpper(method)
+		if !isMethod(p.Methods[i]) {
+			return fmt.Errorf("unknown method %q", method)
+		}
+	}
+	return nil
+}
+
+func isMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
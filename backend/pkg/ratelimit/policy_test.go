@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writePolicies(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadPolicies(t *testing.T) {
+	path := writePolicies(t, `
+default:
+  rate: 5
+  burst: 10
+policies:
+  - name: writes
+    methods: [post, PUT, PATCH, DELETE]
+    rate: 1
+    burst: 3
+  - group: /posts/
+    tier: admin
+    rate: 50
+    burst: 100
+  - group: auth
+    tier: anonymous
+    rate: 0.2
+    burst: 5
+`)
+
+	p, err := LoadPolicies(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		group, method, tier string
+		want                string
+	}{
+		{"posts", "POST", TierUser, "writes"},
+		{"posts", "POST", TierAdmin, "writes"},
+		{"posts", "GET", TierAdmin, "policy-2"},
+		{"posts", "GET", TierUser, "default"},
+		{"auth", "GET", TierAnonymous, "policy-3"},
+		{"users", "GET", TierAnonymous, "default"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, p.Match(tt.group, tt.method, tt.tier).Name, "%s %s %s", tt.method, tt.group, tt.tier)
+	}
+
+	require.Equal(t, Limit{Rate: 1, Burst: 3}, p.Match("users", "DELETE", TierUser).Limit())
+	require.Equal(t, Limit{Rate: 5, Burst: 10}, p.Match("users", "GET", TierUser).Limit())
+}
+
+func TestLoadPolicies_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing default": `policies: []`,
+		"zero burst":      "default: {rate: 1, burst: 0}",
+		"unknown tier":    "default: {rate: 1, burst: 1}\npolicies: [{tier: gold, rate: 1, burst: 1}]",
+		"unknown method":  "default: {rate: 1, burst: 1}\npolicies: [{methods: [FETCH], rate: 1, burst: 1}]",
+		"not yaml":        "default: [",
+		"unknown field":   "default: {rate: 1, brust: 1}",
+		"empty":           "",
+	}
+
+	for name, content := range tests {
+		_, err := LoadPolicies(writePolicies(t, content))
+		require.Error(t, err, name)
+	}
+
+	_, err := LoadPolicies(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestSinglePolicy(t *testing.T) {
+	p := SinglePolicy(5)
+	require.Equal(t, Limit{Rate: 5, Burst: 5}, p.Match("posts", "POST", TierAnonymous).Limit())
+}
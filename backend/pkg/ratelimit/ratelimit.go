@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limit is a token bucket, Rate tokens a second are added up to Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the outcome of taking a token. Reset is how long until the bucket is full again,
+// RetryAfter is only set when the request was not allowed.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+const (
+	DefaultShards  = 64
+	DefaultMaxKeys = 100_000
+	DefaultTTL     = 10 * time.Minute
+)
+
+// Options bounds a MemoryStore. MaxKeys is split evenly across the shards, the least recently
+// used key of a full shard is evicted, and keys unused for TTL are dropped. There are never more
+// shards than MaxKeys, so every shard holds at least one key and the store at most MaxKeys.
+type Options struct {
+	Shards  int
+	MaxKeys int
+	TTL     time.Duration
+}
+
+// MemoryStore keeps a token bucket per key in memory. Keys are spread over shards that each
+// have their own lock, so callers with different keys rarely wait on each other.
+//
+// A dropped key starts again with a full bucket, which is what it would have had anyway as
+// long as TTL is longer than the time a bucket takes to refill.
+type MemoryStore struct {
+	shards []*shard
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+type shard struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	// lru is ordered from most to least recently used
+	lru *list.List
+}
+
+type entry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewMemoryStore creates a MemoryStore, zero options get the defaults
+func NewMemoryStore(opts Options) *MemoryStore {
+	if opts.Shards <= 0 {
+		opts.Shards = DefaultShards
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = DefaultMaxKeys
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	opts.Shards = min(opts.Shards, opts.MaxKeys)
+
+	capacity := opts.MaxKeys / opts.Shards
+	shards := make([]*shard, opts.Shards)
+	for i := range shards {
+		shards[i] = &shard{
+			capacity: capacity,
+			items:    make(map[string]*list.Element),
+			lru:      list.New(),
+		}
+	}
+
+	return &MemoryStore{shards: shards, ttl: opts.TTL, now: time.Now}
+}
+
+// Take takes a token from the key's bucket, creating the bucket if needed. A request that is
+// not allowed takes nothing, so callers that keep retrying are not pushed further back.
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	now := s.now()
+	sh := s.shard(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	limiter := sh.get(key, limit, now, s.ttl)
+
+	// a reservation tells us how long the caller would have to wait, which a bare Allow does not
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return result(limiter, now, false, 0), nil
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return result(limiter, now, false, delay), nil
+	}
+
+	return result(limiter, now, true, 0), nil
+}
+
+// Len returns the number of keys held
+func (s *MemoryStore) Len() int {
+	n := 0
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		n += len(sh.items)
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+func (s *MemoryStore) shard(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// get returns the key's limiter, marking it as used. It must be called with the lock held.
+func (sh *shard) get(key string, limit Limit, now time.Time, ttl time.Duration) *rate.Limiter {
+	sh.expire(now, ttl)
+
+	if el, ok := sh.items[key]; ok {
+		e := el.Value.(*entry)
+		e.lastSeen = now
+		sh.lru.MoveToFront(el)
+
+		// the limit may have changed since the bucket was created
+		if e.limiter.Limit() != rate.Limit(limit.Rate) {
+			e.limiter.SetLimitAt(now, rate.Limit(limit.Rate))
+		}
+		if e.limiter.Burst() != limit.Burst {
+			e.limiter.SetBurstAt(now, limit.Burst)
+		}
+		return e.limiter
+	}
+
+	for sh.lru.Len() >= sh.capacity {
+		sh.remove(sh.lru.Back())
+	}
+
+	e := &entry{key: key, limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst), lastSeen: now}
+	sh.items[key] = sh.lru.PushFront(e)
+	return e.limiter
+}
+
+// expire drops the keys unused for ttl, they are all at the back of the list
+func (sh *shard) expire(now time.Time, ttl time.Duration) {
+	for el := sh.lru.Back(); el != nil; el = sh.lru.Back() {
+		if now.Sub(el.Value.(*entry).lastSeen) < ttl {
+			return
+		}
+		sh.remove(el)
+	}
+}
+
+func (sh *shard) remove(el *list.Element) {
+	sh.lru.Remove(el)
+	delete(sh.items, el.Value.(*entry).key)
+}
+
+func result(limiter *rate.Limiter, now time.Time, allowed bool, retryAfter time.Duration) Result {
+	burst := limiter.Burst()
+	tokens := max(limiter.TokensAt(now), 0)
+
+	var reset time.Duration
+	if missing := float64(burst) - tokens; missing > 0 && limiter.Limit() > 0 {
+		reset = time.Duration(missing / float64(limiter.Limit()) * float64(time.Second))
+	}
+
+	return Result{
+		Allowed:    allowed,
+		Limit:      burst,
+		Remaining:  int(math.Floor(tokens)),
+		Reset:      reset,
+		RetryAfter: retryAfter,
+	}
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore returns a store whose clock only moves when advance is called
+func newTestStore(opts Options) (*MemoryStore, func(time.Duration)) {
+	store := NewMemoryStore(opts)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	return store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	store, advance := newTestStore(Options{})
+	ctx := context.Background()
+	limit := Limit{Rate: 2, Burst: 2}
+
+	res, err := store.Take(ctx, "user-1", limit)
+	require.NoError(t, err)
+	require.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 500 * time.Millisecond}, res)
+
+	res, _ = store.Take(ctx, "user-1", limit)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+	require.Equal(t, time.Second, res.Reset)
+
+	res, _ = store.Take(ctx, "user-1", limit)
+	require.False(t, res.Allowed)
+	require.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// rejected requests take nothing, so waiting RetryAfter is enough
+	advance(500 * time.Millisecond)
+	res, _ = store.Take(ctx, "user-1", limit)
+	require.True(t, res.Allowed)
+
+	// each key has its own bucket
+	res, _ = store.Take(ctx, "user-2", limit)
+	require.True(t, res.Allowed)
+	require.Equal(t, 1, res.Remaining)
+}
+
+func TestMemoryStore_LimitChanges(t *testing.T) {
+	store, _ := newTestStore(Options{})
+	ctx := context.Background()
+
+	_, _ = store.Take(ctx, "user-1", Limit{Rate: 1, Burst: 1})
+	res, _ := store.Take(ctx, "user-1", Limit{Rate: 10, Burst: 10})
+	require.Equal(t, 10, res.Limit)
+}
+
+func TestMemoryStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	store, advance := newTestStore(Options{Shards: 1, MaxKeys: 2})
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	_, _ = store.Take(ctx, "a", limit)
+	advance(time.Millisecond)
+	_, _ = store.Take(ctx, "b", limit)
+	advance(time.Millisecond)
+
+	// a is used again, so b is the one evicted to make room for c
+	res, _ := store.Take(ctx, "a", limit)
+	require.False(t, res.Allowed)
+	_, _ = store.Take(ctx, "c", limit)
+	require.Equal(t, 2, store.Len())
+
+	res, _ = store.Take(ctx, "a", limit)
+	require.False(t, res.Allowed, "a should still have its empty bucket")
+	res, _ = store.Take(ctx, "b", limit)
+	require.True(t, res.Allowed, "b should have been evicted and start again")
+}
+
+func TestMemoryStore_FewerKeysThanShards(t *testing.T) {
+	store, _ := newTestStore(Options{Shards: 8, MaxKeys: 3})
+	for i := range 20 {
+		_, _ = store.Take(context.Background(), fmt.Sprintf("user-%d", i), Limit{Rate: 1, Burst: 1})
+	}
+	require.Len(t, store.shards, 3)
+	require.Equal(t, 3, store.Len())
+}
+
+func TestMemoryStore_ExpiresIdleKeys(t *testing.T) {
+	store, advance := newTestStore(Options{Shards: 1, TTL: time.Minute})
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	_, _ = store.Take(ctx, "a", limit)
+	_, _ = store.Take(ctx, "b", limit)
+	advance(30 * time.Second)
+	_, _ = store.Take(ctx, "b", limit)
+	require.Equal(t, 2, store.Len())
+
+	advance(45 * time.Second)
+	_, _ = store.Take(ctx, "c", limit)
+	require.Equal(t, 2, store.Len(), "a has been idle for longer than the TTL")
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	store := NewMemoryStore(Options{Shards: 4, MaxKeys: 64})
+	ctx := context.Background()
+	limit := Limit{Rate: 1000, Burst: 1000}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				_, err := store.Take(ctx, fmt.Sprintf("user-%d", (i*500+j)%200), limit)
+				require.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, store.Len(), 64)
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcra is the generic cell rate algorithm, the token bucket expressed as the single time at
+// which the bucket will be full again (the TAT). Times are in microseconds from the server's
+// clock, so instances with skewed clocks still agree. A rejected request stores nothing.
+//
+// It returns whether the request is allowed, the requests remaining, the microseconds until a
+// rejected request may retry and the microseconds until the bucket is full.
+var gcra = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local emission = 1000000 / rate
+local tolerance = emission * burst
+
+local tat = tonumber(redis.call("GET", key))
+if not tat or tat < now then
+  tat = now
+end
+
+local new_tat = tat + emission
+local diff = now - (new_tat - tolerance)
+if diff < 0 then
+  return {0, 0, math.ceil(-diff), math.ceil(tat - now)}
+end
+
+local ttl = math.max(math.ceil((new_tat - now) / 1000), 1)
+redis.call("SET", key, string.format("%.0f", new_tat), "PX", ttl)
+
+return {1, math.floor(diff / emission), 0, math.ceil(new_tat - now)}
+`)
+
+// RedisStore keeps the buckets in Redis so every instance of the API shares them. Each key
+// only lives until its bucket is full again, so idle callers take no space.
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisStore creates a RedisStore, keys are stored under prefix
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Take takes a token from the key's bucket, a request that is not allowed takes nothing
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.Rate <= 0 || limit.Burst < 1 {
+		return Result{Limit: max(limit.Burst, 0)}, nil
+	}
+
+	values, err := gcra.Run(ctx, s.client, []string{s.prefix + key}, limit.Burst, limit.Rate).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("error running rate limit script: %w", err)
+	}
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected rate limit script reply %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		Reset:      time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestRedisStore_Take(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := NewRedisStore(client, "ratelimit:")
+	ctx := context.Background()
+	limit := Limit{Rate: 2, Burst: 2}
+
+	res, err := store.Take(ctx, "user-1", limit)
+	require.NoError(t, err)
+	require.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 500 * time.Millisecond}, res)
+	require.True(t, mr.Exists("ratelimit:user-1"))
+
+	res, err = store.Take(ctx, "user-1", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+	require.Equal(t, time.Second, res.Reset)
+
+	res, err = store.Take(ctx, "user-1", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// rejected requests take nothing, so waiting RetryAfter is enough
+	mr.SetTime(time.Date(2025, 1, 1, 0, 0, 0, int(500*time.Millisecond), time.UTC))
+	res, err = store.Take(ctx, "user-1", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	res, err = store.Take(ctx, "user-2", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 1, res.Remaining)
+}
+
+func TestRedisStore_SharedBetweenInstances(t *testing.T) {
+	_, client := newTestRedis(t)
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 3}
+
+	// two API instances talking to the same Redis share one bucket per key
+	first := NewRedisStore(client, "ratelimit:")
+	second := NewRedisStore(client, "ratelimit:")
+
+	for i, store := range []*RedisStore{first, second, first} {
+		res, err := store.Take(ctx, "user-1", limit)
+		require.NoError(t, err)
+		require.True(t, res.Allowed, "request %d", i+1)
+	}
+
+	res, err := second.Take(ctx, "user-1", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+}
+
+func TestRedisStore_KeysExpireWhenFull(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := NewRedisStore(client, "ratelimit:")
+
+	_, err := store.Take(context.Background(), "user-1", Limit{Rate: 1, Burst: 5})
+	require.NoError(t, err)
+	require.Equal(t, time.Second, mr.TTL("ratelimit:user-1"))
+
+	mr.FastForward(time.Second)
+	require.False(t, mr.Exists("ratelimit:user-1"))
+}
+
+func TestRedisStore_Unavailable(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := NewRedisStore(client, "ratelimit:")
+	mr.Close()
+
+	_, err := store.Take(context.Background(), "user-1", Limit{Rate: 1, Burst: 1})
+	require.Error(t, err)
+}
@@ -1,4 +1,4 @@
-import { UserProp, ApiResponseList, ApiResponse, PostProp } from "../types";
+import { UserProp, ApiResponseList, ApiResponse, ApiResponsePage, PostProp } from "../types";
 
 
 const API_KEY = import.meta.env.VITE_X_API_KEY as string;
@@ -28,20 +28,30 @@ export const fetchUsers = async (
 };
 
 export const fetchUserPosts = async (userID: string) => {
-  const response = await fetch(
-    `https://postr-yzs7.onrender.com/posts?userId=${userID}`,
-    {
-      headers: {
-        "X-API-Key": API_KEY,
-      },
+  const posts: PostProp[] = [];
+  let pageNumber = 1;
+  let totalPages = 1;
+
+  do {
+    const response = await fetch(
+      `https://postr-yzs7.onrender.com/posts?userId=${userID}&pageNumber=${pageNumber}&pageSize=100`,
+      {
+        headers: {
+          "X-API-Key": API_KEY,
+        },
+      }
+    );
+
+    if (!response.ok) {
+      throw new Error("Network response was not ok");
     }
-  );
+    const responseData = (await response.json()) as ApiResponsePage<PostProp[]>;
+    posts.push(...responseData.data);
+    totalPages = responseData.pagination.total_pages;
+    pageNumber++;
+  } while (pageNumber <= totalPages);
 
-  if (!response.ok) {
-    throw new Error("Network response was not ok");
-  }
-  const responseData = (await response.json()) as ApiResponse<PostProp[]>;
-  return responseData.data;
+  return posts;
 };
 
 export const fetchUser = async (userID: string) => {
@@ -42,4 +42,8 @@ export interface ApiResponse<T> {
   status: string;
   message: string;
   data: T;
-}
\ No newline at end of file
+}
+
+export interface ApiResponsePage<T> extends ApiResponse<T> {
+  pagination: PaginationProp;
+}